@@ -0,0 +1,46 @@
+resource "ipam_allocate" "example" {
+  pool = "POOL1"
+  hosts = {
+    "host1" = {}
+    "host2" = {}
+  }
+}
+
+data "ipam_dhcp_reservations" "example" {
+  hosts = {
+    for k, v in ipam_allocate.example.hosts : k => {
+      ip            = v.ip
+      prefix_length = v.prefix_length
+      gateway       = v.gateway
+    }
+  }
+  macs = {
+    "host1" = "00:50:56:00:00:01"
+    "host2" = "00:50:56:00:00:02"
+  }
+}
+
+output "isc_hosts" {
+  value = data.ipam_dhcp_reservations.example.isc_hosts
+}
+
+/* 
+isc_hosts = <<EOT
+host host1 {
+  hardware ethernet 00:50:56:00:00:01;
+  fixed-address 1.1.1.1;
+  option subnet-mask 255.255.255.0;
+  option routers 1.1.1.254;
+  option host-name "host1";
+}
+
+host host2 {
+  hardware ethernet 00:50:56:00:00:02;
+  fixed-address 1.1.1.2;
+  option subnet-mask 255.255.255.0;
+  option routers 1.1.1.254;
+  option host-name "host2";
+}
+
+EOT
+*/
@@ -0,0 +1,38 @@
+resource "ipam_allocate" "example" {
+  pool = "POOL1"
+  hosts = {
+    "host1" = {}
+    "host2" = {}
+  }
+}
+
+data "ipam_dns_records" "example" {
+  hosts = {
+    for k, v in ipam_allocate.example.hosts : k => {
+      ip            = v.ip
+      ips           = v.ips
+      prefix_length = v.prefix_length
+    }
+  }
+  zone = "example.com"
+  ttl  = 3600
+}
+
+output "zone_files" {
+  value = data.ipam_dns_records.example.zone_files
+}
+
+/* 
+zone_files = tomap({
+  "1.1.1.in-addr.arpa" = <<-EOT
+  1.1.1.1.in-addr.arpa. 3600 IN PTR host1.example.com.
+  2.1.1.1.in-addr.arpa. 3600 IN PTR host2.example.com.
+
+  EOT
+  "example.com" = <<-EOT
+  host1.example.com. 3600 IN A 1.1.1.1
+  host2.example.com. 3600 IN A 1.1.1.2
+
+  EOT
+})
+*/
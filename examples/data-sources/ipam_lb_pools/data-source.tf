@@ -0,0 +1,36 @@
+resource "ipam_allocate" "example" {
+  pool = "POOL1"
+  hosts = {
+    "cluster1" = {
+      count      = 4
+      contiguous = true
+    }
+  }
+}
+
+data "ipam_lb_pools" "example" {
+  hosts = {
+    for k, v in ipam_allocate.example.hosts : k => {
+      ips = v.ips
+    }
+  }
+}
+
+output "metallb_manifest" {
+  value = data.ipam_lb_pools.example.metallb_manifests["cluster1"]
+}
+
+/* 
+metallb_manifest = <<EOT
+apiVersion: metallb.io/v1beta1
+kind: IPAddressPool
+metadata:
+  name: cluster1
+  namespace: metallb-system
+spec:
+  addresses:
+    - 1.1.1.1-1.1.1.4
+  autoAssign: true
+
+EOT
+*/
@@ -0,0 +1,46 @@
+resource "ipam_allocate" "example" {
+  pool = "POOL1"
+  hosts = {
+    "host1" = {}
+  }
+}
+
+data "ipam_network_config" "example" {
+  hosts = {
+    for k, v in ipam_allocate.example.hosts : k => {
+      ip            = v.ip
+      ips           = v.ips
+      prefix_length = v.prefix_length
+      gateway       = v.gateway
+    }
+  }
+  interface      = "eth0"
+  nameservers    = ["1.1.1.53"]
+  search_domains = ["example.com"]
+}
+
+output "netplan" {
+  value = data.ipam_network_config.example.netplan["host1"]
+}
+
+/* 
+netplan = <<EOT
+network:
+  version: 2
+  ethernets:
+    eth0:
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 1.1.1.1/24
+      routes:
+        - to: 0.0.0.0/0
+          via: 1.1.1.254
+      nameservers:
+        addresses:
+          - 1.1.1.53
+        search:
+          - example.com
+
+EOT
+*/
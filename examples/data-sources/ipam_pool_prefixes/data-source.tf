@@ -0,0 +1,19 @@
+data "ipam_pool_prefixes" "example" {
+  pool = "POOL1"
+}
+
+output "prefixes" {
+  value = data.ipam_pool_prefixes.example.prefixes
+}
+
+/* 
+prefixes = tolist([
+  "1.1.1.1/32",
+  "1.1.1.2/31",
+  "1.1.1.4/30",
+  "1.1.1.8/31",
+  "1.1.1.10/32",
+  "1.1.1.20/32",
+  "1.1.1.30/32",
+])
+*/
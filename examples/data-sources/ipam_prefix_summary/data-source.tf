@@ -0,0 +1,23 @@
+resource "ipam_allocate" "example" {
+  pool = "POOL1"
+  hosts = {
+    "leaf1" = {}
+    "leaf2" = {}
+    "leaf3" = {}
+  }
+}
+
+data "ipam_prefix_summary" "example" {
+  ips = [for h in ipam_allocate.example.hosts : h.ip]
+}
+
+output "prefixes" {
+  value = data.ipam_prefix_summary.example.prefixes
+}
+
+/* 
+prefixes = tolist([
+  "1.1.1.1/32",
+  "1.1.1.2/31",
+])
+*/
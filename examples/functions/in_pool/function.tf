@@ -0,0 +1,30 @@
+locals {
+  pool = {
+    name          = "POOL1"
+    prefix_length = 24
+    gateway       = "10.0.0.254"
+    ranges = [
+      {
+        from_ip = "10.0.0.10"
+        to_ip   = "10.0.0.20"
+      }
+    ]
+    addresses = [
+      {
+        ip = "10.0.0.30"
+      }
+    ]
+  }
+}
+
+provider "ipam" {
+  pools = [local.pool]
+}
+
+output "in_pool" {
+  value = provider::ipam::in_pool(local.pool, "10.0.0.30")
+}
+
+/*
+in_pool = true
+*/
@@ -0,0 +1,7 @@
+output "next" {
+  value = provider::ipam::ip_add("10.0.0.255", 1)
+}
+
+/*
+next = "10.0.1.0"
+*/
@@ -0,0 +1,7 @@
+output "diff" {
+  value = provider::ipam::ip_diff("10.0.1.10", "10.0.0.5")
+}
+
+/*
+diff = 261
+*/
@@ -0,0 +1,7 @@
+output "ip" {
+  value = provider::ipam::normalize("2001:DB8:0:0::0001")
+}
+
+/*
+ip = "2001:db8::1"
+*/
@@ -0,0 +1,7 @@
+output "contains" {
+  value = provider::ipam::range_contains("10.0.0.10", "10.0.0.20", "10.0.0.15")
+}
+
+/*
+contains = true
+*/
@@ -0,0 +1,7 @@
+output "size" {
+  value = provider::ipam::range_size("10.0.0.5", "10.0.0.20")
+}
+
+/*
+size = 16
+*/
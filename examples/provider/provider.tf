@@ -20,4 +20,10 @@ provider "ipam" {
       ]
     }
   ]
+  prefix_pools = [
+    {
+      name     = "PREFIX_POOL1"
+      prefixes = ["10.0.0.0/16"]
+    }
+  ]
 }
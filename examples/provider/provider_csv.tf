@@ -0,0 +1,4 @@
+provider "ipam" {
+  pools_csv       = "pools.csv"
+  assignments_csv = "assignments.csv"
+}
@@ -0,0 +1,10 @@
+provider "ipam" {
+  data_model_pools = [
+    {
+      file             = "data_model.yaml"
+      path             = "apic.tenants.bridge_domains"
+      cidr_field       = "subnets.ip"
+      exclusions_field = "ipam_exclusions"
+    }
+  ]
+}
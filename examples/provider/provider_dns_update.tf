@@ -0,0 +1,23 @@
+provider "ipam" {
+  dns_update = {
+    server         = "192.0.2.53"
+    tsig_key_name  = "terraform"
+    tsig_algorithm = "hmac-sha256"
+    tsig_secret    = var.tsig_secret
+  }
+  pools = [
+    {
+      name              = "MGMT"
+      prefix_length     = 24
+      gateway           = "10.50.0.1"
+      dns_zone          = "example.com"
+      dns_name_template = "{host}-mgmt"
+      ranges = [
+        {
+          from_ip = "10.50.0.10"
+          to_ip   = "10.50.0.254"
+        }
+      ]
+    }
+  ]
+}
@@ -0,0 +1,19 @@
+provider "ipam" {
+  pools = [
+    {
+      name          = "LEAF_LOOPBACKS"
+      prefix_length = 32
+      gateway       = "10.90.0.0"
+      host_offset = {
+        pattern = "^leaf-(\\d+)$"
+        offset  = -1
+      }
+      ranges = [
+        {
+          from_ip = "10.90.0.1"
+          to_ip   = "10.90.0.254"
+        }
+      ]
+    }
+  ]
+}
@@ -0,0 +1,35 @@
+provider "ipam" {
+  pools = [
+    {
+      name          = "SITE1"
+      prefix_length = 24
+      gateway       = "10.80.0.1"
+      ranges = [
+        {
+          from_ip = "10.80.0.10"
+          to_ip   = "10.80.0.49"
+          labels  = { rack = "r11" }
+        },
+        {
+          from_ip = "10.80.0.50"
+          to_ip   = "10.80.0.89"
+          labels  = { rack = "r12" }
+        }
+      ]
+      addresses = [
+        {
+          ip     = "10.80.0.250"
+          labels = { role = "oob" }
+        }
+      ]
+    }
+  ]
+}
+
+resource "ipam_allocate" "site1" {
+  pool = "SITE1"
+  hosts = {
+    "r12-srv01" = { selector = { rack = "r12" } }
+    "console"   = { selector = { role = "oob" } }
+  }
+}
@@ -0,0 +1,27 @@
+provider "ipam" {
+  pools = [
+    {
+      name           = "SERVERS"
+      prefix_length  = 24
+      gateway        = "10.70.0.1"
+      overflow_pools = ["SERVERS_OVERFLOW"]
+      ranges = [
+        {
+          from_ip = "10.70.0.10"
+          to_ip   = "10.70.0.254"
+        }
+      ]
+    },
+    {
+      name          = "SERVERS_OVERFLOW"
+      prefix_length = 24
+      gateway       = "10.71.0.1"
+      ranges = [
+        {
+          from_ip = "10.71.0.10"
+          to_ip   = "10.71.0.254"
+        }
+      ]
+    }
+  ]
+}
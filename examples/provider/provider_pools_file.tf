@@ -0,0 +1,3 @@
+provider "ipam" {
+  pools_file = "pools.yaml"
+}
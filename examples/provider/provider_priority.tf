@@ -0,0 +1,27 @@
+provider "ipam" {
+  pools = [
+    {
+      name          = "EDGE"
+      prefix_length = 24
+      gateway       = "10.110.0.1"
+      ranges = [
+        {
+          from_ip  = "10.110.0.10"
+          to_ip    = "10.110.0.99"
+          priority = 10
+          weight   = 3
+        },
+        {
+          from_ip = "10.110.0.100"
+          to_ip   = "10.110.0.199"
+        }
+      ]
+      addresses = [
+        {
+          ip       = "10.110.0.250"
+          priority = -1
+        }
+      ]
+    }
+  ]
+}
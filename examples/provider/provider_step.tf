@@ -0,0 +1,17 @@
+provider "ipam" {
+  pools = [
+    {
+      name          = "LEGACY"
+      prefix_length = 24
+      gateway       = "10.100.0.1"
+      ranges = [
+        {
+          from_ip = "10.100.0.2"
+          to_ip   = "10.100.0.249"
+          step    = 4
+          align   = 4
+        }
+      ]
+    }
+  ]
+}
@@ -0,0 +1,17 @@
+provider "ipam" {
+  pools = [
+    {
+      name            = "LEAFS"
+      prefix_length   = 24
+      gateway         = "10.60.0.1"
+      warn_threshold  = 80
+      error_threshold = 95
+      ranges = [
+        {
+          from_ip = "10.60.0.11"
+          to_ip   = "10.60.0.30"
+        }
+      ]
+    }
+  ]
+}
@@ -2,7 +2,7 @@ resource "ipam_allocate" "example" {
   pool = "POOL1"
   hosts = {
     "host1" = {}
-    "host2" = {}
+    "host2" = { count = 2 }
   }
 }
 
@@ -13,14 +13,31 @@ output "hosts" {
 /* 
 hosts = tomap({
   "host1" = {
+    "affinity_group" = tostring(null)
+    "contiguous" = tobool(null)
+    "count" = 1
     "gateway" = "1.1.1.254"
     "ip" = "1.1.1.1"
+    "ips" = tolist([
+      "1.1.1.1",
+    ])
+    "pool" = "POOL1"
     "prefix_length" = 24
+    "selector" = tomap(null) /* of string */
   }
   "host2" = {
+    "affinity_group" = tostring(null)
+    "contiguous" = tobool(null)
+    "count" = 2
     "gateway" = "1.1.1.254"
     "ip" = "1.1.1.2"
+    "ips" = tolist([
+      "1.1.1.2",
+      "1.1.1.3",
+    ])
+    "pool" = "POOL1"
     "prefix_length" = 24
+    "selector" = tomap(null) /* of string */
   }
 })
 */
@@ -0,0 +1,33 @@
+resource "ipam_group_allocate" "example" {
+  pool = "POOL1"
+  groups = {
+    "vlan10" = {
+      members = {
+        "router1" = {}
+        "router2" = {}
+      }
+    }
+  }
+}
+
+output "groups" {
+  value = ipam_group_allocate.example.groups
+}
+
+/* 
+groups = tomap({
+  "vlan10" = {
+    "gateway" = "1.1.1.254"
+    "members" = tomap({
+      "router1" = {
+        "ip" = "1.1.1.2"
+      }
+      "router2" = {
+        "ip" = "1.1.1.3"
+      }
+    })
+    "prefix_length" = 24
+    "vip" = "1.1.1.1"
+  }
+})
+*/
@@ -0,0 +1,30 @@
+resource "ipam_link_allocate" "example" {
+  pool = "POOL1"
+  links = {
+    "spine1-leaf1" = { a_side = "spine1", b_side = "leaf1" }
+    "spine1-leaf2" = { a_side = "spine1", b_side = "leaf2" }
+  }
+}
+
+output "links" {
+  value = ipam_link_allocate.example.links
+}
+
+/* 
+links = tomap({
+  "spine1-leaf1" = {
+    "a_ip" = "1.1.1.2"
+    "a_side" = "spine1"
+    "b_ip" = "1.1.1.3"
+    "b_side" = "leaf1"
+    "prefix" = "1.1.1.2/31"
+  }
+  "spine1-leaf2" = {
+    "a_ip" = "1.1.1.4"
+    "a_side" = "spine1"
+    "b_ip" = "1.1.1.5"
+    "b_side" = "leaf2"
+    "prefix" = "1.1.1.4/31"
+  }
+})
+*/
@@ -0,0 +1,30 @@
+resource "ipam_prefix_allocate" "example" {
+  pool = "PREFIX_POOL1"
+  prefixes = {
+    "vlan10" = { prefix_length = 24 }
+    "link1"  = { prefix_length = 31 }
+  }
+}
+
+output "prefixes" {
+  value = ipam_prefix_allocate.example.prefixes
+}
+
+/* 
+prefixes = tomap({
+  "link1" = {
+    "first_ip" = "10.0.1.0"
+    "gateway" = "10.0.1.0"
+    "last_ip" = "10.0.1.1"
+    "prefix" = "10.0.1.0/31"
+    "prefix_length" = 31
+  }
+  "vlan10" = {
+    "first_ip" = "10.0.0.1"
+    "gateway" = "10.0.0.1"
+    "last_ip" = "10.0.0.254"
+    "prefix" = "10.0.0.0/24"
+    "prefix_length" = 24
+  }
+})
+*/
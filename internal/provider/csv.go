@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+// providerAssignment is a known assignment of an IP to a host, e.g. from a
+// brownfield inventory. An empty Pool matches all pools.
+type providerAssignment struct {
+	Pool string
+	Host string
+	IP   netip.Addr
+}
+
+// csvRow is a row of a CSV file with the line it starts at.
+type csvRow struct {
+	line   int
+	values map[string]string
+}
+
+// readCsv reads a CSV file with a header row and returns every row as a map
+// of lower case column names to trimmed values. Unknown columns and empty rows
+// are ignored.
+func readCsv(name string, required ...string) ([]csvRow, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	columns, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("missing header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]string, len(columns))
+	known := make(map[string]bool)
+	for i, column := range columns {
+		// CSV exports of Excel start with a byte order mark
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		known[header[i]] = true
+	}
+	for _, column := range required {
+		if !known[column] {
+			return nil, fmt.Errorf("missing column '%s'", column)
+		}
+	}
+
+	rows := make([]csvRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := csvRow{line: line, values: make(map[string]string, len(header))}
+		empty := true
+		for i, value := range record {
+			if i < len(header) {
+				row.values[header[i]] = strings.TrimSpace(value)
+				empty = empty && row.values[header[i]] == ""
+			}
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// loadPoolsCsv reads pools from a CSV file with one range, subnet or address
+// per row. Rows of the same pool are merged in file order.
+func loadPoolsCsv(name string) ([]providerDataPool, error) {
+	rows, err := readCsv(name, "pool")
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]providerDataPool, 0)
+	index := make(map[string]int)
+	for _, r := range rows {
+		row, line := r.values, r.line
+		if row["pool"] == "" {
+			return nil, fmt.Errorf("line %d has no 'pool'", line)
+		}
+		p, ok := index[row["pool"]]
+		if !ok {
+			p = len(pools)
+			index[row["pool"]] = p
+			pools = append(pools, providerDataPool{
+				Name:         types.StringValue(row["pool"]),
+				PrefixLength: types.Int64Null(),
+				Gateway:      types.StringNull(),
+			})
+		}
+
+		var prefix netip.Prefix
+		if row["cidr"] != "" {
+			if prefix, err = netip.ParsePrefix(row["cidr"]); err != nil {
+				return nil, fmt.Errorf("line %d: '%s' is not a valid prefix in CIDR notation", line, row["cidr"])
+			}
+		}
+		prefixLength := types.Int64Null()
+		if prefix.IsValid() {
+			prefixLength = types.Int64Value(int64(prefix.Bits()))
+		}
+		if row["prefix_length"] != "" {
+			bits, err := strconv.ParseInt(row["prefix_length"], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: '%s' is not a valid prefix length", line, row["prefix_length"])
+			}
+			prefixLength = types.Int64Value(bits)
+		}
+		// the address part of the CIDR is the gateway, e.g. '10.0.0.1/24'
+		gateway := types.StringNull()
+		if prefix.IsValid() && prefix.Addr() != prefix.Masked().Addr() {
+			gateway = types.StringValue(prefix.Addr().String())
+		}
+		if row["gateway"] != "" {
+			gateway = types.StringValue(row["gateway"])
+		}
+
+		switch {
+		case row["from"] != "" || row["to"] != "":
+			pools[p].Ranges = append(pools[p].Ranges, providerDataPoolRange{
+				FromIP:       types.StringValue(row["from"]),
+				ToIP:         types.StringValue(row["to"]),
+				PrefixLength: prefixLength,
+				Gateway:      gateway,
+			})
+		case row["ip"] != "":
+			pools[p].Addresses = append(pools[p].Addresses, providerDataPoolAddress{
+				IP:           types.StringValue(row["ip"]),
+				PrefixLength: prefixLength,
+				Gateway:      gateway,
+			})
+		case prefix.IsValid():
+			if subnetTooLarge(prefix) {
+				return nil, fmt.Errorf("line %d: subnet '%s' has more than %d addresses, use 'from' and 'to' instead", line, row["cidr"], 1<<maxSubnetBits)
+			}
+			// all usable addresses of the subnet except the gateway
+			first, last := ipam.UsableRange(prefix.Masked())
+			var excluded []ipam.Range
+			if gw, err := netip.ParseAddr(gateway.ValueString()); err == nil {
+				excluded = append(excluded, ipam.Range{From: gw, To: gw})
+			}
+			for _, r := range (ipam.Range{From: first, To: last}).Exclude(excluded...) {
+				if r.From == r.To {
+					pools[p].Addresses = append(pools[p].Addresses, providerDataPoolAddress{
+						IP:           types.StringValue(r.From.String()),
+						PrefixLength: prefixLength,
+						Gateway:      gateway,
+					})
+					continue
+				}
+				pools[p].Ranges = append(pools[p].Ranges, providerDataPoolRange{
+					FromIP:       types.StringValue(r.From.String()),
+					ToIP:         types.StringValue(r.To.String()),
+					PrefixLength: prefixLength,
+					Gateway:      gateway,
+				})
+			}
+		default:
+			return nil, fmt.Errorf("line %d has neither 'cidr', 'from' and 'to' nor 'ip'", line)
+		}
+	}
+	return pools, nil
+}
+
+// loadAssignmentsCsv reads known assignments from a CSV file with the columns
+// host and ip and an optional pool column.
+func loadAssignmentsCsv(name string) ([]providerAssignment, error) {
+	rows, err := readCsv(name, "host", "ip")
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]providerAssignment, 0, len(rows))
+	for _, r := range rows {
+		row, line := r.values, r.line
+		if row["host"] == "" {
+			return nil, fmt.Errorf("line %d has no 'host'", line)
+		}
+		ip, err := netip.ParseAddr(row["ip"])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: '%s' is not a valid IP address", line, row["ip"])
+		}
+		assignments = append(assignments, providerAssignment{Pool: row["pool"], Host: row["host"], IP: ip})
+	}
+	return assignments, nil
+}
+
+// adoptAssignments adds the known assignments of hosts without addresses to
+// existing and returns the addresses of all other assignments of the pool
+// chain, which must not be allocated. Assignments whose address is not part
+// of the pool chain or does not match the request of the host are returned
+// as rejected and not adopted.
+func adoptAssignments(assignments []providerAssignment, chain []*ipam.Pool, requests map[string]ipam.Request, existing map[string][]netip.Addr) (reserved []netip.Addr, rejected []providerAssignment) {
+	names := make(map[string]bool, len(chain))
+	for _, p := range chain {
+		names[p.Name] = true
+	}
+	inUse := make(map[netip.Addr]bool)
+	for _, addrs := range existing {
+		for _, addr := range addrs {
+			inUse[addr] = true
+		}
+	}
+
+	var addresses map[netip.Addr]ipam.Address
+	adopted := make(map[string]bool)
+	for _, a := range assignments {
+		if a.Pool != "" && !names[a.Pool] {
+			continue
+		}
+		request, ok := requests[a.Host]
+		if !ok || (len(existing[a.Host]) != 0 && !adopted[a.Host]) || len(existing[a.Host]) >= request.Count || inUse[a.IP] {
+			reserved = append(reserved, a.IP)
+			continue
+		}
+		if addresses == nil {
+			addresses = make(map[netip.Addr]ipam.Address)
+			for _, p := range chain {
+				for _, pa := range p.Expand() {
+					if _, ok := addresses[pa.IP]; !ok {
+						addresses[pa.IP] = pa
+					}
+				}
+			}
+		}
+		if pa, ok := addresses[a.IP]; !ok || (request.Match != nil && !request.Match(pa)) {
+			rejected = append(rejected, a)
+			reserved = append(reserved, a.IP)
+			continue
+		}
+		existing[a.Host] = append(existing[a.Host], a.IP)
+		adopted[a.Host] = true
+		inUse[a.IP] = true
+	}
+	return reserved, rejected
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+func TestLoadPoolsCsv(t *testing.T) {
+	pools, err := loadPoolsCsv("testdata/pools.csv")
+	if err != nil {
+		t.Fatalf("loadPoolsCsv() error = %v", err)
+	}
+	want := []providerDataPool{
+		{
+			Name:         types.StringValue("CSV_POOL1"),
+			PrefixLength: types.Int64Null(),
+			Gateway:      types.StringNull(),
+			Ranges: []providerDataPoolRange{
+				{FromIP: types.StringValue("10.4.0.1"), ToIP: types.StringValue("10.4.0.10"), PrefixLength: types.Int64Value(24), Gateway: types.StringValue("10.4.0.254")},
+			},
+			Addresses: []providerDataPoolAddress{
+				{IP: types.StringValue("10.4.0.100"), PrefixLength: types.Int64Value(24), Gateway: types.StringValue("10.4.0.254")},
+			},
+		},
+		{
+			Name:         types.StringValue("CSV_POOL2"),
+			PrefixLength: types.Int64Null(),
+			Gateway:      types.StringNull(),
+			Ranges: []providerDataPoolRange{
+				{FromIP: types.StringValue("10.5.0.2"), ToIP: types.StringValue("10.5.0.6"), PrefixLength: types.Int64Value(29), Gateway: types.StringValue("10.5.0.1")},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pools, want) {
+		t.Errorf("loadPoolsCsv() = %+v, want %+v", pools, want)
+	}
+}
+
+func TestLoadAssignmentsCsv(t *testing.T) {
+	assignments, err := loadAssignmentsCsv("testdata/assignments.csv")
+	if err != nil {
+		t.Fatalf("loadAssignmentsCsv() error = %v", err)
+	}
+	want := []providerAssignment{
+		{Pool: "CSV_POOL1", Host: "host1", IP: netip.MustParseAddr("10.4.0.5")},
+		{Pool: "CSV_POOL1", Host: "legacy1", IP: netip.MustParseAddr("10.4.0.1")},
+		{Pool: "", Host: "legacy2", IP: netip.MustParseAddr("10.4.0.2")},
+	}
+	if !reflect.DeepEqual(assignments, want) {
+		t.Errorf("loadAssignmentsCsv() = %+v, want %+v", assignments, want)
+	}
+}
+
+func TestAdoptAssignments(t *testing.T) {
+	chain := []*ipam.Pool{
+		{Name: "POOL1", Ranges: []ipam.Range{{From: netip.MustParseAddr("10.0.0.1"), To: netip.MustParseAddr("10.0.0.10")}}},
+		{Name: "POOL2", Ranges: []ipam.Range{{From: netip.MustParseAddr("10.0.1.1"), To: netip.MustParseAddr("10.0.1.10")}}},
+	}
+	assignments := []providerAssignment{
+		{Pool: "POOL1", Host: "host1", IP: netip.MustParseAddr("10.0.0.5")},
+		{Pool: "", Host: "host2", IP: netip.MustParseAddr("10.0.1.5")},
+		{Pool: "", Host: "host3", IP: netip.MustParseAddr("10.9.0.5")},
+		{Pool: "POOL1", Host: "host4", IP: netip.MustParseAddr("10.0.0.6")},
+		{Pool: "POOL1", Host: "legacy1", IP: netip.MustParseAddr("10.0.0.1")},
+		{Pool: "OTHER", Host: "host1", IP: netip.MustParseAddr("10.0.0.7")},
+	}
+	requests := map[string]ipam.Request{
+		"host1": {Count: 1},
+		"host2": {Count: 1},
+		"host3": {Count: 1},
+		"host4": {Count: 1, Match: func(a ipam.Address) bool { return a.IP != netip.MustParseAddr("10.0.0.6") }},
+	}
+	existing := map[string][]netip.Addr{}
+	reserved, rejected := adoptAssignments(assignments, chain, requests, existing)
+
+	wantExisting := map[string][]netip.Addr{
+		"host1": {netip.MustParseAddr("10.0.0.5")},
+		"host2": {netip.MustParseAddr("10.0.1.5")},
+	}
+	if !reflect.DeepEqual(existing, wantExisting) {
+		t.Errorf("adoptAssignments() existing = %v, want %v", existing, wantExisting)
+	}
+	wantReserved := []netip.Addr{netip.MustParseAddr("10.9.0.5"), netip.MustParseAddr("10.0.0.6"), netip.MustParseAddr("10.0.0.1")}
+	if !reflect.DeepEqual(reserved, wantReserved) {
+		t.Errorf("adoptAssignments() reserved = %v, want %v", reserved, wantReserved)
+	}
+	wantRejected := []providerAssignment{assignments[2], assignments[3]}
+	if !reflect.DeepEqual(rejected, wantRejected) {
+		t.Errorf("adoptAssignments() rejected = %v, want %v", rejected, wantRejected)
+	}
+}
+
+func TestLoadCsvErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		load    func(string) error
+	}{
+		{"pools without pool column", "name,cidr\nPOOL,10.0.0.0/24\n", func(f string) error { _, err := loadPoolsCsv(f); return err }},
+		{"pools without pool", "pool,cidr\n,10.0.0.0/24\n", func(f string) error { _, err := loadPoolsCsv(f); return err }},
+		{"pools with invalid cidr", "pool,cidr\nPOOL,10.0.0.0\n", func(f string) error { _, err := loadPoolsCsv(f); return err }},
+		{"pools with large cidr", "pool,cidr\nPOOL,2001:db8::/64\n", func(f string) error { _, err := loadPoolsCsv(f); return err }},
+		{"pools without addresses", "pool,gateway\nPOOL,10.0.0.1\n", func(f string) error { _, err := loadPoolsCsv(f); return err }},
+		{"empty file", "", func(f string) error { _, err := loadPoolsCsv(f); return err }},
+		{"assignments without ip column", "host\nhost1\n", func(f string) error { _, err := loadAssignmentsCsv(f); return err }},
+		{"assignments with invalid ip", "host,ip\nhost1,10.0.0\n", func(f string) error { _, err := loadAssignmentsCsv(f); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, "file.csv")
+			if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.load(file); err == nil {
+				t.Errorf("accepted %q", tt.content)
+			}
+		})
+	}
+}
+
+func TestLoadCsvErrorLines(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		load    func(string) error
+		want    string
+	}{
+		{"pool,cidr\n,\nPOOL,10.0.0.0/24\n,\nPOOL,10.0.0.0\n", func(f string) error { _, err := loadPoolsCsv(f); return err }, "line 5: '10.0.0.0' is not a valid prefix in CIDR notation"},
+		{"pool,cidr\n\"POOL\n1\",10.0.0.0/24\nPOOL,10.0.0.0\n", func(f string) error { _, err := loadPoolsCsv(f); return err }, "line 4: '10.0.0.0' is not a valid prefix in CIDR notation"},
+		{"host,ip\n,,\nhost1,10.0.0.1\nhost2,10.0.0\n", func(f string) error { _, err := loadAssignmentsCsv(f); return err }, "line 4: '10.0.0' is not a valid IP address"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(dir, "file.csv")
+		if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.load(file); err == nil || err.Error() != tt.want {
+			t.Errorf("load(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
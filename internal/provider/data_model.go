@@ -0,0 +1,266 @@
+package provider
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+	"gopkg.in/yaml.v3"
+)
+
+// loadDataModelPools derives one pool per object found at the key path of a
+// YAML data model, e.g. one pool per bridge domain of a Network-as-Code data
+// model. Objects without a value for the CIDR field are skipped, objects
+// without a gateway are an error.
+func loadDataModelPools(source providerDataDataModelPool) ([]providerDataPool, error) {
+	content, err := os.ReadFile(source.File.ValueString())
+	if err != nil {
+		return nil, err
+	}
+	var root any
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+
+	nameTemplate := "{name}"
+	if !source.NameField.IsNull() {
+		nameTemplate = "{" + source.NameField.ValueString() + "}"
+	}
+	if !source.NameTemplate.IsNull() {
+		nameTemplate = source.NameTemplate.ValueString()
+	}
+
+	pools := make([]providerDataPool, 0)
+	for i, o := range dataModelCollect(root, "", splitDataModelPath(source.Path.ValueString()), nil) {
+		object := o.node
+		cidr, ok := dataModelString(object, source.CidrField.ValueString())
+		if !ok {
+			continue
+		}
+		name, err := o.expand(nameTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("object %d at '%s' has no %s", i, source.Path.ValueString(), err.Error())
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' of '%s' is not a valid prefix in CIDR notation", cidr, name)
+		}
+		network := prefix.Masked()
+		if subnetTooLarge(network) {
+			return nil, fmt.Errorf("subnet '%s' of '%s' has more than %d addresses", cidr, name, 1<<maxSubnetBits)
+		}
+
+		// the address part of the CIDR is the gateway, e.g. '10.0.0.1/24'
+		var gateway netip.Addr
+		if prefix.Addr() != network.Addr() {
+			gateway = prefix.Addr()
+		}
+		if !source.GatewayField.IsNull() {
+			value, ok := dataModelString(object, source.GatewayField.ValueString())
+			if ok {
+				if gateway, err = parseDataModelAddr(value); err != nil {
+					return nil, fmt.Errorf("gateway '%s' of '%s' is not a valid IP address", value, name)
+				}
+			}
+		}
+
+		if !gateway.IsValid() {
+			return nil, fmt.Errorf("subnet '%s' of '%s' at '%s' has no gateway", cidr, name, source.Path.ValueString())
+		}
+
+		excluded := []ipam.Range{{From: gateway, To: gateway}}
+		if !source.ExclusionsField.IsNull() {
+			for _, value := range dataModelStrings(object, source.ExclusionsField.ValueString()) {
+				r, err := parseDataModelRange(value)
+				if err != nil {
+					return nil, fmt.Errorf("exclusion '%s' of '%s' is not a valid IP address, range or prefix", value, name)
+				}
+				excluded = append(excluded, r)
+			}
+		}
+
+		first, last := ipam.UsableRange(network)
+		pool := providerDataPool{
+			Name:         types.StringValue(name),
+			PrefixLength: types.Int64Value(int64(network.Bits())),
+			Gateway:      types.StringValue(gateway.String()),
+		}
+		for _, r := range (ipam.Range{From: first, To: last}).Exclude(excluded...) {
+			if r.From == r.To {
+				pool.Addresses = append(pool.Addresses, providerDataPoolAddress{
+					IP:           types.StringValue(r.From.String()),
+					PrefixLength: types.Int64Null(),
+					Gateway:      types.StringNull(),
+				})
+				continue
+			}
+			pool.Ranges = append(pool.Ranges, providerDataPoolRange{
+				FromIP:       types.StringValue(r.From.String()),
+				ToIP:         types.StringValue(r.To.String()),
+				PrefixLength: types.Int64Null(),
+				Gateway:      types.StringNull(),
+			})
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+func splitDataModelPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// dataModelObject is an object of a data model and the objects it is nested
+// in by their key, e.g. the tenant of a bridge domain as 'tenants'.
+type dataModelObject struct {
+	node    any
+	parents map[string]any
+}
+
+// dataModelCollect returns all objects at the key path. Lists are traversed
+// implicitly, so 'tenants.bridge_domains' returns the bridge domains of all
+// tenants.
+func dataModelCollect(node any, key string, keys []string, parents map[string]any) []dataModelObject {
+	if list, ok := node.([]any); ok {
+		objects := make([]dataModelObject, 0)
+		for _, element := range list {
+			objects = append(objects, dataModelCollect(element, key, keys, parents)...)
+		}
+		return objects
+	}
+	if len(keys) == 0 {
+		return []dataModelObject{{node: node, parents: parents}}
+	}
+	if key != "" {
+		nested := make(map[string]any, len(parents)+1)
+		for k, v := range parents {
+			nested[k] = v
+		}
+		nested[key] = node
+		parents = nested
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+	child, ok := m[keys[0]]
+	if !ok {
+		return nil
+	}
+	return dataModelCollect(child, keys[0], keys[1:], parents)
+}
+
+var dataModelPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// expand replaces every '{field}' of a template with the value of the field.
+// Fields starting with the key of a parent object, e.g. '{tenants.name}', are
+// looked up in that object.
+func (o dataModelObject) expand(template string) (string, error) {
+	var missing string
+	value := dataModelPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		field := placeholder[1 : len(placeholder)-1]
+		node := o.node
+		if key, rest, ok := strings.Cut(field, "."); ok {
+			if parent, ok := o.parents[key]; ok {
+				node, field = parent, rest
+			}
+		}
+		value, ok := dataModelString(node, field)
+		if !ok && missing == "" {
+			missing = placeholder[1 : len(placeholder)-1]
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("'%s'", missing)
+	}
+	return value, nil
+}
+
+// dataModelLookup returns the value of a field path relative to an object.
+// The first element is used for lists along the path.
+func dataModelLookup(node any, path string) (any, bool) {
+	for _, key := range splitDataModelPath(path) {
+		if list, ok := node.([]any); ok {
+			if len(list) == 0 {
+				return nil, false
+			}
+			node = list[0]
+		}
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[key]; !ok || node == nil {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func dataModelString(node any, path string) (string, bool) {
+	value, ok := dataModelLookup(node, path)
+	if !ok {
+		return "", false
+	}
+	switch value.(type) {
+	case []any, map[string]any:
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+func dataModelStrings(node any, path string) []string {
+	value, ok := dataModelLookup(node, path)
+	if !ok {
+		return nil
+	}
+	list, ok := value.([]any)
+	if !ok {
+		list = []any{value}
+	}
+	values := make([]string, 0, len(list))
+	for _, element := range list {
+		values = append(values, fmt.Sprint(element))
+	}
+	return values
+}
+
+// parseDataModelAddr parses an IP address with an optional prefix length.
+func parseDataModelAddr(s string) (netip.Addr, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Addr(), nil
+	}
+	return netip.ParseAddr(s)
+}
+
+// parseDataModelRange parses an IP address, a prefix or a range in the form
+// 'from-to'.
+func parseDataModelRange(s string) (ipam.Range, error) {
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		f, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil {
+			return ipam.Range{}, err
+		}
+		t, err := netip.ParseAddr(strings.TrimSpace(to))
+		if err != nil {
+			return ipam.Range{}, err
+		}
+		return ipam.Range{From: f, To: t}, nil
+	}
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return ipam.Range{From: prefix.Masked().Addr(), To: ipam.LastAddr(prefix)}, nil
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return ipam.Range{}, err
+	}
+	return ipam.Range{From: ip, To: ip}, nil
+}
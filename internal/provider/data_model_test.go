@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestLoadDataModelPools(t *testing.T) {
+	source := providerDataDataModelPool{
+		File:            types.StringValue("testdata/data_model.yaml"),
+		Path:            types.StringValue("apic.tenants.bridge_domains"),
+		NameField:       types.StringNull(),
+		CidrField:       types.StringValue("subnets.ip"),
+		GatewayField:    types.StringValue("gateway"),
+		ExclusionsField: types.StringValue("exclusions"),
+	}
+	pools, err := loadDataModelPools(source)
+	if err != nil {
+		t.Fatalf("loadDataModelPools() error = %v", err)
+	}
+	want := []providerDataPool{
+		{
+			Name:         types.StringValue("WEB"),
+			PrefixLength: types.Int64Value(24),
+			Gateway:      types.StringValue("10.10.0.1"),
+			Ranges: []providerDataPoolRange{
+				{FromIP: types.StringValue("10.10.0.10"), ToIP: types.StringValue("10.10.0.127"), PrefixLength: types.Int64Null(), Gateway: types.StringNull()},
+			},
+		},
+		{
+			Name:         types.StringValue("DB"),
+			PrefixLength: types.Int64Value(28),
+			Gateway:      types.StringValue("10.10.1.14"),
+			Ranges: []providerDataPoolRange{
+				{FromIP: types.StringValue("10.10.1.1"), ToIP: types.StringValue("10.10.1.13"), PrefixLength: types.Int64Null(), Gateway: types.StringNull()},
+			},
+		},
+		{
+			Name:         types.StringValue("APP"),
+			PrefixLength: types.Int64Value(30),
+			Gateway:      types.StringValue("10.20.0.1"),
+			Addresses: []providerDataPoolAddress{
+				{IP: types.StringValue("10.20.0.2"), PrefixLength: types.Int64Null(), Gateway: types.StringNull()},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pools, want) {
+		t.Errorf("loadDataModelPools() = %+v, want %+v", pools, want)
+	}
+}
+
+func TestLoadDataModelPoolsNameTemplate(t *testing.T) {
+	source := providerDataDataModelPool{
+		File:         types.StringValue("testdata/data_model.yaml"),
+		Path:         types.StringValue("apic.tenants.bridge_domains"),
+		NameTemplate: types.StringValue("{tenants.name}_{name}"),
+		CidrField:    types.StringValue("subnets.ip"),
+		GatewayField: types.StringValue("gateway"),
+	}
+	pools, err := loadDataModelPools(source)
+	if err != nil {
+		t.Fatalf("loadDataModelPools() error = %v", err)
+	}
+	names := make([]string, 0, len(pools))
+	for _, p := range pools {
+		names = append(names, p.Name.ValueString())
+	}
+	if want := []string{"PROD_WEB", "PROD_DB", "DEV_APP"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("loadDataModelPools() names = %v, want %v", names, want)
+	}
+}
+
+func TestLoadDataModelPoolsErrors(t *testing.T) {
+	large := filepath.Join(t.TempDir(), "large.yaml")
+	if err := os.WriteFile(large, []byte("bridge_domains:\n  - name: V6\n    subnet: 2001:db8::1/64\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]providerDataDataModelPool{
+		"large subnet": {
+			File:      types.StringValue(large),
+			Path:      types.StringValue("bridge_domains"),
+			CidrField: types.StringValue("subnet"),
+		},
+		"missing template field": {
+			File:         types.StringValue("testdata/data_model.yaml"),
+			Path:         types.StringValue("apic.tenants.bridge_domains"),
+			NameTemplate: types.StringValue("{tenants.alias}_{name}"),
+			CidrField:    types.StringValue("subnets.ip"),
+		},
+		"missing file": {
+			File:      types.StringValue("testdata/missing.yaml"),
+			Path:      types.StringValue("apic.tenants.bridge_domains"),
+			CidrField: types.StringValue("subnets.ip"),
+		},
+		"missing name": {
+			File:      types.StringValue("testdata/data_model.yaml"),
+			Path:      types.StringValue("apic.tenants.bridge_domains"),
+			NameField: types.StringValue("alias"),
+			CidrField: types.StringValue("subnets.ip"),
+		},
+		"invalid cidr": {
+			File:      types.StringValue("testdata/data_model.yaml"),
+			Path:      types.StringValue("apic.tenants.bridge_domains"),
+			CidrField: types.StringValue("name"),
+		},
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadDataModelPools(source); err == nil {
+				t.Errorf("loadDataModelPools() accepted %+v", source)
+			}
+		})
+	}
+}
+
+func TestLoadDataModelPoolsMissingGateway(t *testing.T) {
+	source := providerDataDataModelPool{
+		File:      types.StringValue("testdata/data_model.yaml"),
+		Path:      types.StringValue("apic.tenants.bridge_domains"),
+		CidrField: types.StringValue("subnets.ip"),
+	}
+	_, err := loadDataModelPools(source)
+	if want := "subnet '10.10.1.0/28' of 'DB' at 'apic.tenants.bridge_domains' has no gateway"; err == nil || err.Error() != want {
+		t.Errorf("loadDataModelPools() error = %v, want %s", err, want)
+	}
+}
+
+func TestParseDataModelRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		from    string
+		to      string
+		invalid bool
+	}{
+		{value: "10.0.0.1", from: "10.0.0.1", to: "10.0.0.1"},
+		{value: "10.0.0.1 - 10.0.0.5", from: "10.0.0.1", to: "10.0.0.5"},
+		{value: "10.0.0.8/29", from: "10.0.0.8", to: "10.0.0.15"},
+		{value: "10.0.0.9/29", from: "10.0.0.8", to: "10.0.0.15"},
+		{value: "host1", invalid: true},
+		{value: "10.0.0.1-host1", invalid: true},
+	}
+	for _, tt := range tests {
+		r, err := parseDataModelRange(tt.value)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("parseDataModelRange(%q) accepted an invalid value", tt.value)
+			}
+			continue
+		}
+		if err != nil || r.From.String() != tt.from || r.To.String() != tt.to {
+			t.Errorf("parseDataModelRange(%q) = %v, %v, want %s-%s", tt.value, r, err, tt.from, tt.to)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ datasource.DataSource = (*ipamDhcpReservationsDataSource)(nil)
+
+func NewIpamDhcpReservationsDataSource() datasource.DataSource {
+	return &ipamDhcpReservationsDataSource{}
+}
+
+type ipamDhcpReservationsDataSource struct{}
+
+func (d *ipamDhcpReservationsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_dhcp_reservations"
+}
+
+func (d *ipamDhcpReservationsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Render allocated hosts as Kea and ISC dhcpd host reservations. The `hosts` attribute of an `ipam_allocate` resource can be passed with a `for` expression selecting `ip`, `prefix_length` and `gateway` of every host. Hosts without a MAC address are skipped.",
+
+		Attributes: map[string]schema.Attribute{
+			"hosts": schema.MapNestedAttribute{
+				MarkdownDescription: "A map of host IDs and their addresses, e.g. `{ for k, v in ipam_allocate.example.hosts : k => { ip = v.ip, prefix_length = v.prefix_length, gateway = v.gateway } }`. The host ID is used as hostname and may only contain letters, digits, `-`, `_` and `.`.",
+				Required:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ip": schema.StringAttribute{
+							MarkdownDescription: "IP address.",
+							Required:            true,
+						},
+						"prefix_length": schema.Int64Attribute{
+							MarkdownDescription: "Prefix length, rendered as subnet mask option for IPv4 addresses.",
+							Optional:            true,
+						},
+						"gateway": schema.StringAttribute{
+							MarkdownDescription: "Gateway IP, rendered as routers option for IPv4 addresses.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"macs": schema.MapAttribute{
+				MarkdownDescription: "A map of host IDs and their MAC addresses, e.g. `{ host1 = \"00:50:56:00:00:01\" }`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"kea_reservations": schema.StringAttribute{
+				MarkdownDescription: "Kea `reservations` list in JSON format. IPv4 and IPv6 addresses must be used in the respective Kea server configuration.",
+				Computed:            true,
+			},
+			"isc_hosts": schema.StringAttribute{
+				MarkdownDescription: "ISC dhcpd `host` declarations.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+type DhcpReservations struct {
+	Hosts           map[string]DhcpReservationsHost `tfsdk:"hosts"`
+	Macs            map[string]types.String         `tfsdk:"macs"`
+	KeaReservations types.String                    `tfsdk:"kea_reservations"`
+	IscHosts        types.String                    `tfsdk:"isc_hosts"`
+}
+
+type DhcpReservationsHost struct {
+	Ip           types.String `tfsdk:"ip"`
+	PrefixLength types.Int64  `tfsdk:"prefix_length"`
+	Gateway      types.String `tfsdk:"gateway"`
+}
+
+// dhcpReservation is a single host reservation.
+type dhcpReservation struct {
+	Hostname     string
+	Mac          net.HardwareAddr
+	IP           netip.Addr
+	PrefixLength int
+	Gateway      netip.Addr
+}
+
+type keaOptionData struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+type keaReservation struct {
+	Hostname    string          `json:"hostname"`
+	HwAddress   string          `json:"hw-address"`
+	IpAddress   string          `json:"ip-address,omitempty"`
+	IpAddresses []string        `json:"ip-addresses,omitempty"`
+	OptionData  []keaOptionData `json:"option-data,omitempty"`
+}
+
+func (d *ipamDhcpReservationsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config DhcpReservations
+
+	// Read config
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	reservations, diags := getDhcpReservations(&config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	kea, err := renderKeaReservations(reservations)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to render Kea reservations", err.Error())
+		return
+	}
+	config.KeaReservations = types.StringValue(kea)
+	config.IscHosts = types.StringValue(renderIscHosts(reservations))
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// dhcpHostname matches host IDs which can be used unquoted in ISC dhcpd
+// 'host' declarations.
+var dhcpHostname = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// getDhcpReservations returns the reservations of all hosts with a MAC
+// address, sorted by host ID.
+func getDhcpReservations(config *DhcpReservations) ([]dhcpReservation, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	hosts := make([]string, 0, len(config.Hosts))
+	for h := range config.Hosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	reservations := make([]dhcpReservation, 0, len(hosts))
+	for _, h := range hosts {
+		mac, ok := config.Macs[h]
+		if !ok || mac.ValueString() == "" {
+			continue
+		}
+		if !dhcpHostname.MatchString(h) {
+			diags.AddError("Invalid host ID", fmt.Sprintf("Host ID '%s' is not a valid DHCP host name, which may only contain letters, digits, '-', '_' and '.'.", h))
+			return nil, diags
+		}
+		host := config.Hosts[h]
+		reservation := dhcpReservation{Hostname: h}
+		var err error
+		if reservation.Mac, err = net.ParseMAC(mac.ValueString()); err != nil {
+			diags.AddError("Invalid MAC address", fmt.Sprintf("MAC address '%s' of '%s' is invalid.", mac.ValueString(), h))
+			return nil, diags
+		}
+		if reservation.IP, err = netip.ParseAddr(host.Ip.ValueString()); err != nil {
+			diags.AddError("Invalid IP address", fmt.Sprintf("IP '%s' of '%s' is not a valid address.", host.Ip.ValueString(), h))
+			return nil, diags
+		}
+		reservation.PrefixLength = int(host.PrefixLength.ValueInt64())
+		if !host.Gateway.IsNull() {
+			if reservation.Gateway, err = netip.ParseAddr(host.Gateway.ValueString()); err != nil {
+				diags.AddError("Invalid IP address", fmt.Sprintf("Gateway '%s' of '%s' is not a valid address.", host.Gateway.ValueString(), h))
+				return nil, diags
+			}
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, diags
+}
+
+func renderKeaReservations(reservations []dhcpReservation) (string, error) {
+	kea := make([]keaReservation, 0, len(reservations))
+	for _, r := range reservations {
+		k := keaReservation{Hostname: r.Hostname, HwAddress: r.Mac.String()}
+		if r.IP.Is6() {
+			k.IpAddresses = []string{r.IP.String()}
+		} else {
+			k.IpAddress = r.IP.String()
+			if r.PrefixLength > 0 && r.PrefixLength <= 32 {
+				k.OptionData = append(k.OptionData, keaOptionData{Name: "subnet-mask", Data: subnetMask(r.PrefixLength)})
+			}
+			if r.Gateway.IsValid() {
+				k.OptionData = append(k.OptionData, keaOptionData{Name: "routers", Data: r.Gateway.String()})
+			}
+		}
+		kea = append(kea, k)
+	}
+	b, err := json.MarshalIndent(kea, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func renderIscHosts(reservations []dhcpReservation) string {
+	var b strings.Builder
+	for i, r := range reservations {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "host %s {\n", r.Hostname)
+		fmt.Fprintf(&b, "  hardware ethernet %s;\n", r.Mac.String())
+		if r.IP.Is6() {
+			fmt.Fprintf(&b, "  fixed-address6 %s;\n", r.IP.String())
+		} else {
+			fmt.Fprintf(&b, "  fixed-address %s;\n", r.IP.String())
+			if r.PrefixLength > 0 && r.PrefixLength <= 32 {
+				fmt.Fprintf(&b, "  option subnet-mask %s;\n", subnetMask(r.PrefixLength))
+			}
+			if r.Gateway.IsValid() {
+				fmt.Fprintf(&b, "  option routers %s;\n", r.Gateway.String())
+			}
+		}
+		fmt.Fprintf(&b, "  option host-name \"%s\";\n", r.Hostname)
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// subnetMask returns the dotted decimal IPv4 subnet mask of a prefix length.
+func subnetMask(prefixLength int) string {
+	return net.IP(net.CIDRMask(prefixLength, 32)).String()
+}
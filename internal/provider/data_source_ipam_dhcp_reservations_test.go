@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamDhcpReservations(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamDhcpReservationsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ipam_dhcp_reservations.test", "isc_hosts", testIscHosts),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamDhcpReservationsConfig() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+			"host3" = {}
+		}
+	}
+
+	data "ipam_dhcp_reservations" "test" {
+		hosts = {
+			for k, v in ipam_allocate.test.hosts : k => {
+				ip            = v.ip
+				prefix_length = v.prefix_length
+				gateway       = v.gateway
+			}
+		}
+		macs = {
+			"host1" = "00:50:56:00:00:01"
+			"host2" = "00-50-56-00-00-02"
+		}
+	}
+	`
+}
+
+const testIscHosts = `host host1 {
+  hardware ethernet 00:50:56:00:00:01;
+  fixed-address 10.1.0.1;
+  option subnet-mask 255.255.255.0;
+  option routers 10.1.0.254;
+  option host-name "host1";
+}
+
+host host2 {
+  hardware ethernet 00:50:56:00:00:02;
+  fixed-address 10.1.0.2;
+  option subnet-mask 255.255.255.0;
+  option routers 10.1.0.254;
+  option host-name "host2";
+}
+`
+
+const testKeaReservations = `[
+  {
+    "hostname": "host1",
+    "hw-address": "00:50:56:00:00:01",
+    "ip-address": "10.1.0.1",
+    "option-data": [
+      {
+        "name": "subnet-mask",
+        "data": "255.255.255.0"
+      },
+      {
+        "name": "routers",
+        "data": "10.1.0.254"
+      }
+    ]
+  },
+  {
+    "hostname": "host2",
+    "hw-address": "00:50:56:00:00:02",
+    "ip-address": "10.1.0.2",
+    "option-data": [
+      {
+        "name": "subnet-mask",
+        "data": "255.255.255.0"
+      },
+      {
+        "name": "routers",
+        "data": "10.1.0.254"
+      }
+    ]
+  },
+  {
+    "hostname": "host4",
+    "hw-address": "00:50:56:00:00:04",
+    "ip-addresses": [
+      "2001:db8::4"
+    ]
+  }
+]`
+
+func TestDhcpReservationsRender(t *testing.T) {
+	config := DhcpReservations{
+		Hosts: map[string]DhcpReservationsHost{
+			"host1": {Ip: types.StringValue("10.1.0.1"), PrefixLength: types.Int64Value(24), Gateway: types.StringValue("10.1.0.254")},
+			"host2": {Ip: types.StringValue("10.1.0.2"), PrefixLength: types.Int64Value(24), Gateway: types.StringValue("10.1.0.254")},
+			"host3": {Ip: types.StringValue("10.1.0.3"), PrefixLength: types.Int64Value(24), Gateway: types.StringValue("10.1.0.254")},
+			"host4": {Ip: types.StringValue("2001:db8::4"), PrefixLength: types.Int64Value(64), Gateway: types.StringNull()},
+		},
+		Macs: map[string]types.String{
+			"host1": types.StringValue("00:50:56:00:00:01"),
+			"host2": types.StringValue("00-50-56-00-00-02"),
+			"host4": types.StringValue("0050.5600.0004"),
+		},
+	}
+	reservations, diags := getDhcpReservations(&config)
+	if diags.HasError() {
+		t.Fatalf("getDhcpReservations() error = %v", diags)
+	}
+	kea, err := renderKeaReservations(reservations)
+	if err != nil {
+		t.Fatalf("renderKeaReservations() error = %v", err)
+	}
+	if kea != testKeaReservations {
+		t.Errorf("renderKeaReservations() = %s, want %s", kea, testKeaReservations)
+	}
+	if isc := renderIscHosts(reservations[:2]); isc != testIscHosts {
+		t.Errorf("renderIscHosts() = %s, want %s", isc, testIscHosts)
+	}
+
+	config.Macs["host3"] = types.StringValue("invalid")
+	if _, diags := getDhcpReservations(&config); !diags.HasError() {
+		t.Errorf("getDhcpReservations() accepted an invalid MAC address")
+	}
+
+	delete(config.Macs, "host3")
+	for _, h := range []string{"host 5", "host{5", "host;5", "\"host5\""} {
+		config.Hosts[h] = DhcpReservationsHost{Ip: types.StringValue("10.1.0.5"), PrefixLength: types.Int64Value(24), Gateway: types.StringNull()}
+		config.Macs[h] = types.StringValue("00:50:56:00:00:05")
+		if _, diags := getDhcpReservations(&config); !diags.HasError() {
+			t.Errorf("getDhcpReservations() accepted host ID %q", h)
+		}
+		delete(config.Hosts, h)
+		delete(config.Macs, h)
+	}
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ datasource.DataSource = (*ipamDnsRecordsDataSource)(nil)
+
+func NewIpamDnsRecordsDataSource() datasource.DataSource {
+	return &ipamDnsRecordsDataSource{}
+}
+
+type ipamDnsRecordsDataSource struct{}
+
+func (d *ipamDnsRecordsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_dns_records"
+}
+
+func (d *ipamDnsRecordsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Render allocated hosts as forward A/AAAA and reverse PTR records. The `hosts` attribute of an `ipam_allocate` resource can be passed with a `for` expression selecting `ip`, `ips` and `prefix_length` of every host. Reverse zones are cut at the octet (IPv4) or nibble (IPv6) boundary of the host prefix length, e.g. `2.0.192.in-addr.arpa` for `192.0.2.0/24`.",
+
+		Attributes: map[string]schema.Attribute{
+			"hosts": schema.MapNestedAttribute{
+				MarkdownDescription: "A map of host IDs and their addresses, e.g. `{ for k, v in ipam_allocate.example.hosts : k => { ip = v.ip, ips = v.ips, prefix_length = v.prefix_length } }`. The host ID is used as name within the forward zone.",
+				Required:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ip": schema.StringAttribute{
+							MarkdownDescription: "IP address.",
+							Required:            true,
+						},
+						"ips": schema.ListAttribute{
+							MarkdownDescription: "All IP addresses. Takes precedence over `ip`.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
+						"prefix_length": schema.Int64Attribute{
+							MarkdownDescription: "Prefix length, used to determine the reverse zone. Defaults to `24` for IPv4 and `64` for IPv6 addresses.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"zone": schema.StringAttribute{
+				MarkdownDescription: "Forward zone, e.g. `example.com`.",
+				Required:            true,
+			},
+			"ttl": schema.Int64Attribute{
+				MarkdownDescription: "TTL of the rendered zone file records. The zone default is used if not set.",
+				Optional:            true,
+			},
+			"rfc2317": schema.BoolAttribute{
+				MarkdownDescription: "Place PTR records of IPv4 subnets longer than /24 in RFC 2317 classless zones, e.g. `0/26.2.0.192.in-addr.arpa`, and add CNAME records pointing to them to the parent zone.",
+				Optional:            true,
+			},
+			"records": schema.ListNestedAttribute{
+				MarkdownDescription: "All records, sorted by host ID.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"zone": schema.StringAttribute{
+							MarkdownDescription: "Zone of the record.",
+							Computed:            true,
+						},
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Fully qualified record name.",
+							Computed:            true,
+						},
+						"type": schema.StringAttribute{
+							MarkdownDescription: "Record type, one of `A`, `AAAA`, `PTR` or `CNAME`.",
+							Computed:            true,
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "Record value.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"zone_files": schema.MapAttribute{
+				MarkdownDescription: "A map of zones and their records as BIND zone file snippets.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+type DnsRecords struct {
+	Hosts     map[string]DnsRecordsHost `tfsdk:"hosts"`
+	Zone      types.String              `tfsdk:"zone"`
+	Ttl       types.Int64               `tfsdk:"ttl"`
+	Rfc2317   types.Bool                `tfsdk:"rfc2317"`
+	Records   []DnsRecordsRecord        `tfsdk:"records"`
+	ZoneFiles map[string]types.String   `tfsdk:"zone_files"`
+}
+
+type DnsRecordsHost struct {
+	Ip           types.String `tfsdk:"ip"`
+	Ips          types.List   `tfsdk:"ips"`
+	PrefixLength types.Int64  `tfsdk:"prefix_length"`
+}
+
+type DnsRecordsRecord struct {
+	Zone  types.String `tfsdk:"zone"`
+	Name  types.String `tfsdk:"name"`
+	Type  types.String `tfsdk:"type"`
+	Value types.String `tfsdk:"value"`
+}
+
+func (d *ipamDnsRecordsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config DnsRecords
+
+	// Read config
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	hosts, diags := getDnsHosts(ctx, config.Hosts)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	records := getDnsRecords(hosts, config.Zone.ValueString(), config.Rfc2317.ValueBool())
+	config.Records = make([]DnsRecordsRecord, 0, len(records))
+	for _, r := range records {
+		config.Records = append(config.Records, DnsRecordsRecord{
+			Zone:  types.StringValue(r.Zone),
+			Name:  types.StringValue(r.Name),
+			Type:  types.StringValue(r.Type),
+			Value: types.StringValue(r.Value),
+		})
+	}
+	config.ZoneFiles = make(map[string]types.String)
+	for zone, file := range renderZoneFiles(records, config.Ttl.ValueInt64()) {
+		config.ZoneFiles[zone] = types.StringValue(file)
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// getDnsHosts converts the hosts of the configuration.
+func getDnsHosts(ctx context.Context, hosts map[string]DnsRecordsHost) ([]dnsHost, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	result := make([]dnsHost, 0, len(hosts))
+	for h, host := range hosts {
+		addresses := []string{host.Ip.ValueString()}
+		if !host.Ips.IsNull() && !host.Ips.IsUnknown() {
+			addresses = nil
+			diags.Append(host.Ips.ElementsAs(ctx, &addresses, false)...)
+			if diags.HasError() {
+				return nil, diags
+			}
+		}
+		d := dnsHost{Name: h, PrefixLength: int(host.PrefixLength.ValueInt64())}
+		for _, address := range addresses {
+			ip, err := netip.ParseAddr(address)
+			if err != nil {
+				diags.AddError("Invalid IP address", fmt.Sprintf("IP '%s' of '%s' is not a valid address.", address, h))
+				return nil, diags
+			}
+			d.IPs = append(d.IPs, ip)
+		}
+		result = append(result, d)
+	}
+	return result, diags
+}
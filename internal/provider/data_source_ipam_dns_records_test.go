@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamDnsRecords(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamDnsRecordsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "records.#", "4"),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "records.0.name", "host1.example.com."),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "records.0.type", "A"),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "records.0.value", "10.1.0.1"),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "records.1.name", "1.0.1.10.in-addr.arpa."),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "records.1.type", "PTR"),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "zone_files.example.com", "host1.example.com. 3600 IN A 10.1.0.1\nhost2.example.com. 3600 IN A 10.1.0.2\n"),
+					resource.TestCheckResourceAttr("data.ipam_dns_records.test", "zone_files.0.1.10.in-addr.arpa", "1.0.1.10.in-addr.arpa. 3600 IN PTR host1.example.com.\n2.0.1.10.in-addr.arpa. 3600 IN PTR host2.example.com.\n"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamDnsRecordsConfig() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+		}
+	}
+
+	data "ipam_dns_records" "test" {
+		hosts = {
+			for k, v in ipam_allocate.test.hosts : k => {
+				ip            = v.ip
+				ips           = v.ips
+				prefix_length = v.prefix_length
+			}
+		}
+		zone = "example.com"
+		ttl  = 3600
+	}
+	`
+}
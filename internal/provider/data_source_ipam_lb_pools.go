@@ -0,0 +1,267 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ datasource.DataSource = (*ipamLbPoolsDataSource)(nil)
+
+func NewIpamLbPoolsDataSource() datasource.DataSource {
+	return &ipamLbPoolsDataSource{}
+}
+
+type ipamLbPoolsDataSource struct{}
+
+func (d *ipamLbPoolsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_lb_pools"
+}
+
+func (d *ipamLbPoolsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Render Kubernetes load balancer IP pools as MetalLB `IPAddressPool` and Cilium `CiliumLoadBalancerIPPool` manifests. Service VIPs are allocated per cluster by an `ipam_allocate` resource with `count` and `contiguous` set, whose `hosts` attribute can be passed with a `for` expression selecting `ips` of every host.",
+
+		Attributes: map[string]schema.Attribute{
+			"hosts": schema.MapNestedAttribute{
+				MarkdownDescription: "A map of cluster names and their addresses, e.g. `{ for k, v in ipam_allocate.example.hosts : k => { ips = v.ips } }`. The cluster name is used as pool name and must be a valid Kubernetes object name, e.g. `cluster1`.",
+				Required:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ips": schema.ListAttribute{
+							MarkdownDescription: "All IP addresses. Consecutive addresses are rendered as a single block.",
+							ElementType:         types.StringType,
+							Required:            true,
+						},
+					},
+				},
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of the MetalLB `IPAddressPool`, which must be a valid Kubernetes namespace. Defaults to `metallb-system`.",
+				Optional:            true,
+			},
+			"auto_assign": schema.BoolAttribute{
+				MarkdownDescription: "Assign addresses of the MetalLB pools to services automatically. Defaults to `true`.",
+				Optional:            true,
+			},
+			"blocks": schema.MapAttribute{
+				MarkdownDescription: "A map of cluster names and their blocks of consecutive addresses, e.g. `10.0.0.10-10.0.0.19`.",
+				ElementType:         types.ListType{ElemType: types.StringType},
+				Computed:            true,
+			},
+			"metallb_manifests": schema.MapAttribute{
+				MarkdownDescription: "A map of cluster names and their MetalLB `IPAddressPool` manifest in YAML format.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"cilium_manifests": schema.MapAttribute{
+				MarkdownDescription: "A map of cluster names and their Cilium `CiliumLoadBalancerIPPool` manifest in YAML format.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+type LbPools struct {
+	Hosts            map[string]LbPoolsHost    `tfsdk:"hosts"`
+	Namespace        types.String              `tfsdk:"namespace"`
+	AutoAssign       types.Bool                `tfsdk:"auto_assign"`
+	Blocks           map[string][]types.String `tfsdk:"blocks"`
+	MetallbManifests map[string]types.String   `tfsdk:"metallb_manifests"`
+	CiliumManifests  map[string]types.String   `tfsdk:"cilium_manifests"`
+}
+
+type LbPoolsHost struct {
+	Ips []types.String `tfsdk:"ips"`
+}
+
+// lbBlock is a block of consecutive addresses.
+type lbBlock struct {
+	From netip.Addr
+	To   netip.Addr
+}
+
+func (b lbBlock) String() string {
+	if b.From == b.To {
+		return netip.PrefixFrom(b.From, b.From.BitLen()).String()
+	}
+	return b.From.String() + "-" + b.To.String()
+}
+
+type k8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type metallbIPAddressPool struct {
+	ApiVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   k8sMetadata `yaml:"metadata"`
+	Spec       struct {
+		Addresses  []string `yaml:"addresses"`
+		AutoAssign bool     `yaml:"autoAssign"`
+	} `yaml:"spec"`
+}
+
+type ciliumLoadBalancerIPPool struct {
+	ApiVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   k8sMetadata `yaml:"metadata"`
+	Spec       struct {
+		Blocks []ciliumBlock `yaml:"blocks"`
+	} `yaml:"spec"`
+}
+
+type ciliumBlock struct {
+	Cidr  string `yaml:"cidr,omitempty"`
+	Start string `yaml:"start,omitempty"`
+	Stop  string `yaml:"stop,omitempty"`
+}
+
+func (d *ipamLbPoolsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config LbPools
+
+	// Read config
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	namespace := "metallb-system"
+	if !config.Namespace.IsNull() {
+		namespace = config.Namespace.ValueString()
+	}
+	if !isDns1123Label(namespace) {
+		resp.Diagnostics.AddError("Invalid namespace", fmt.Sprintf("Namespace '%s' is not a valid Kubernetes namespace, which must consist of at most 63 lower case alphanumeric characters or '-' and start and end with an alphanumeric character.", namespace))
+		return
+	}
+	autoAssign := true
+	if !config.AutoAssign.IsNull() {
+		autoAssign = config.AutoAssign.ValueBool()
+	}
+
+	config.Blocks = make(map[string][]types.String, len(config.Hosts))
+	config.MetallbManifests = make(map[string]types.String, len(config.Hosts))
+	config.CiliumManifests = make(map[string]types.String, len(config.Hosts))
+	for h, host := range config.Hosts {
+		if !isDns1123Subdomain(h) {
+			resp.Diagnostics.AddError("Invalid pool name", fmt.Sprintf("Cluster name '%s' is not a valid Kubernetes object name, which must consist of at most 253 lower case alphanumeric characters, '-' or '.' and start and end with an alphanumeric character.", h))
+			return
+		}
+		blocks, diags := getLbBlocks(h, host.Ips)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		metallb, err := renderMetallbPool(h, namespace, autoAssign, blocks)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to render MetalLB manifest", err.Error())
+			return
+		}
+		cilium, err := renderCiliumPool(h, blocks)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to render Cilium manifest", err.Error())
+			return
+		}
+		for _, b := range blocks {
+			config.Blocks[h] = append(config.Blocks[h], types.StringValue(b.String()))
+		}
+		config.MetallbManifests[h] = types.StringValue(metallb)
+		config.CiliumManifests[h] = types.StringValue(cilium)
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// getLbBlocks returns the sorted blocks of consecutive addresses of a host.
+func getLbBlocks(host string, ips []types.String) ([]lbBlock, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	addrs := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip.ValueString())
+		if err != nil {
+			diags.AddError("Invalid IP address", fmt.Sprintf("IP '%s' of '%s' is not a valid address.", ip.ValueString(), host))
+			return nil, diags
+		}
+		addrs = append(addrs, addr.Unmap())
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Less(addrs[j]) })
+
+	blocks := make([]lbBlock, 0)
+	for _, addr := range addrs {
+		if n := len(blocks); n > 0 {
+			if blocks[n-1].To == addr {
+				continue
+			}
+			if blocks[n-1].To.Next() == addr {
+				blocks[n-1].To = addr
+				continue
+			}
+		}
+		blocks = append(blocks, lbBlock{From: addr, To: addr})
+	}
+	return blocks, diags
+}
+
+func renderMetallbPool(name, namespace string, autoAssign bool, blocks []lbBlock) (string, error) {
+	pool := metallbIPAddressPool{ApiVersion: "metallb.io/v1beta1", Kind: "IPAddressPool", Metadata: k8sMetadata{Name: name, Namespace: namespace}}
+	pool.Spec.Addresses = make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		pool.Spec.Addresses = append(pool.Spec.Addresses, b.String())
+	}
+	pool.Spec.AutoAssign = autoAssign
+	return marshalYaml(pool)
+}
+
+func renderCiliumPool(name string, blocks []lbBlock) (string, error) {
+	pool := ciliumLoadBalancerIPPool{ApiVersion: "cilium.io/v2alpha1", Kind: "CiliumLoadBalancerIPPool", Metadata: k8sMetadata{Name: name}}
+	pool.Spec.Blocks = make([]ciliumBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if b.From == b.To {
+			pool.Spec.Blocks = append(pool.Spec.Blocks, ciliumBlock{Cidr: b.String()})
+		} else {
+			pool.Spec.Blocks = append(pool.Spec.Blocks, ciliumBlock{Start: b.From.String(), Stop: b.To.String()})
+		}
+	}
+	return marshalYaml(pool)
+}
+
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// isDns1123Label returns true if s is a valid RFC 1123 label as required for
+// Kubernetes namespaces.
+func isDns1123Label(s string) bool {
+	return len(s) <= 63 && dns1123Label.MatchString(s)
+}
+
+// isDns1123Subdomain returns true if s is a valid RFC 1123 subdomain as
+// required for the names of most Kubernetes objects.
+func isDns1123Subdomain(s string) bool {
+	if len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !dns1123Label.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamLbPools(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamLbPoolsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ipam_lb_pools.test", "blocks.cluster1.0", "10.1.0.1-10.1.0.4"),
+					resource.TestCheckResourceAttr("data.ipam_lb_pools.test", "blocks.cluster2.0", "10.1.0.5-10.1.0.6"),
+					resource.TestCheckResourceAttr("data.ipam_lb_pools.test", "metallb_manifests.cluster1", testMetallbPool),
+					resource.TestCheckResourceAttr("data.ipam_lb_pools.test", "cilium_manifests.cluster1", testCiliumPool),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamLbPoolsConfig() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"cluster1" = {
+				count      = 4
+				contiguous = true
+			}
+			"cluster2" = {
+				count      = 2
+				contiguous = true
+			}
+		}
+	}
+
+	data "ipam_lb_pools" "test" {
+		hosts = {
+			for k, v in ipam_allocate.test.hosts : k => {
+				ips = v.ips
+			}
+		}
+	}
+	`
+}
+
+const testMetallbPool = `apiVersion: metallb.io/v1beta1
+kind: IPAddressPool
+metadata:
+  name: cluster1
+  namespace: metallb-system
+spec:
+  addresses:
+    - 10.1.0.1-10.1.0.4
+  autoAssign: true
+`
+
+const testCiliumPool = `apiVersion: cilium.io/v2alpha1
+kind: CiliumLoadBalancerIPPool
+metadata:
+  name: cluster1
+spec:
+  blocks:
+    - start: 10.1.0.1
+      stop: 10.1.0.4
+`
+
+func TestGetLbBlocks(t *testing.T) {
+	ips := []types.String{
+		types.StringValue("10.0.0.12"),
+		types.StringValue("10.0.0.10"),
+		types.StringValue("10.0.0.11"),
+		types.StringValue("10.0.0.11"),
+		types.StringValue("10.0.0.20"),
+		types.StringValue("2001:db8::ff"),
+		types.StringValue("2001:db8::100"),
+	}
+	blocks, diags := getLbBlocks("cluster1", ips)
+	if diags.HasError() {
+		t.Fatalf("getLbBlocks() error = %v", diags)
+	}
+	var got []string
+	for _, b := range blocks {
+		got = append(got, b.String())
+	}
+	want := []string{"10.0.0.10-10.0.0.12", "10.0.0.20/32", "2001:db8::ff-2001:db8::100"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getLbBlocks() = %v, want %v", got, want)
+	}
+
+	metallb, err := renderMetallbPool("cluster1", "lb", false, blocks[:2])
+	if err != nil {
+		t.Fatalf("renderMetallbPool() error = %v", err)
+	}
+	wantMetallb := `apiVersion: metallb.io/v1beta1
+kind: IPAddressPool
+metadata:
+  name: cluster1
+  namespace: lb
+spec:
+  addresses:
+    - 10.0.0.10-10.0.0.12
+    - 10.0.0.20/32
+  autoAssign: false
+`
+	if metallb != wantMetallb {
+		t.Errorf("renderMetallbPool() = %s, want %s", metallb, wantMetallb)
+	}
+
+	cilium, err := renderCiliumPool("cluster1", blocks[1:])
+	if err != nil {
+		t.Fatalf("renderCiliumPool() error = %v", err)
+	}
+	wantCilium := `apiVersion: cilium.io/v2alpha1
+kind: CiliumLoadBalancerIPPool
+metadata:
+  name: cluster1
+spec:
+  blocks:
+    - cidr: 10.0.0.20/32
+    - start: 2001:db8::ff
+      stop: 2001:db8::100
+`
+	if cilium != wantCilium {
+		t.Errorf("renderCiliumPool() = %s, want %s", cilium, wantCilium)
+	}
+
+	if _, diags := getLbBlocks("cluster1", []types.String{types.StringValue("10.0.0.256")}); !diags.HasError() {
+		t.Errorf("getLbBlocks() accepted an invalid address")
+	}
+}
+
+func TestIsDns1123(t *testing.T) {
+	tests := []struct {
+		name      string
+		label     bool
+		subdomain bool
+	}{
+		{"cluster1", true, true},
+		{"lb.cluster-1", false, true},
+		{"Cluster1", false, false},
+		{"cluster_1", false, false},
+		{"-cluster", false, false},
+		{"cluster.", false, false},
+		{"", false, false},
+		{strings.Repeat("a", 64), false, true},
+		{strings.Repeat("a", 254), false, false},
+	}
+	for _, tt := range tests {
+		if got := isDns1123Label(tt.name); got != tt.label {
+			t.Errorf("isDns1123Label(%q) = %v, want %v", tt.name, got, tt.label)
+		}
+		if got := isDns1123Subdomain(tt.name); got != tt.subdomain {
+			t.Errorf("isDns1123Subdomain(%q) = %v, want %v", tt.name, got, tt.subdomain)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+	"gopkg.in/yaml.v3"
+)
+
+var _ datasource.DataSource = (*ipamNetworkConfigDataSource)(nil)
+
+func NewIpamNetworkConfigDataSource() datasource.DataSource {
+	return &ipamNetworkConfigDataSource{}
+}
+
+type ipamNetworkConfigDataSource struct {
+	pools []providerDataPool
+}
+
+func (d *ipamNetworkConfigDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_network_config"
+}
+
+func (d *ipamNetworkConfigDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Render allocated hosts as netplan and cloud-init network configuration with static addresses. The `hosts` attribute of an `ipam_allocate` resource can be passed with a `for` expression selecting `ip`, `ips`, `prefix_length` and `gateway` of every host. The `prefix_length` and `gateway` of a host belong to `ip`, addresses of the other address family use the prefix length and gateway of the configured pool containing them.",
+
+		Attributes: map[string]schema.Attribute{
+			"hosts": schema.MapNestedAttribute{
+				MarkdownDescription: "A map of host IDs and their addresses, e.g. `{ for k, v in ipam_allocate.example.hosts : k => { ip = v.ip, ips = v.ips, prefix_length = v.prefix_length, gateway = v.gateway } }`.",
+				Required:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ip": schema.StringAttribute{
+							MarkdownDescription: "IP address.",
+							Required:            true,
+						},
+						"ips": schema.ListAttribute{
+							MarkdownDescription: "All IP addresses. Defaults to `ip`.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
+						"prefix_length": schema.Int64Attribute{
+							MarkdownDescription: "Prefix length of all addresses of the same address family as `ip`. Defaults to `32` for IPv4 and `128` for IPv6 addresses.",
+							Optional:            true,
+						},
+						"gateway": schema.StringAttribute{
+							MarkdownDescription: "Gateway IP, rendered as IPv4 or IPv6 default route. Gateways outside of the prefix are marked as on-link. A default route of the other address family is derived from the pool containing the addresses of that family.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"interface": schema.StringAttribute{
+				MarkdownDescription: "Interface name, e.g. `eth0`.",
+				Required:            true,
+			},
+			"macs": schema.MapAttribute{
+				MarkdownDescription: "A map of host IDs and their MAC addresses. If set, the interface is matched by MAC address and renamed to `interface`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"nameservers": schema.ListAttribute{
+				MarkdownDescription: "DNS server addresses.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"search_domains": schema.ListAttribute{
+				MarkdownDescription: "DNS search domains.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"mtu": schema.Int64Attribute{
+				MarkdownDescription: "Interface MTU.",
+				Optional:            true,
+			},
+			"netplan": schema.MapAttribute{
+				MarkdownDescription: "A map of host IDs and their netplan configuration in YAML format.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"cloud_init_v1": schema.MapAttribute{
+				MarkdownDescription: "A map of host IDs and their cloud-init network configuration version 1 in YAML format.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"cloud_init_v2": schema.MapAttribute{
+				MarkdownDescription: "A map of host IDs and their cloud-init network configuration version 2 in YAML format.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+type NetworkConfig struct {
+	Hosts         map[string]NetworkConfigHost `tfsdk:"hosts"`
+	Interface     types.String                 `tfsdk:"interface"`
+	Macs          map[string]types.String      `tfsdk:"macs"`
+	Nameservers   []types.String               `tfsdk:"nameservers"`
+	SearchDomains []types.String               `tfsdk:"search_domains"`
+	Mtu           types.Int64                  `tfsdk:"mtu"`
+	Netplan       map[string]types.String      `tfsdk:"netplan"`
+	CloudInitV1   map[string]types.String      `tfsdk:"cloud_init_v1"`
+	CloudInitV2   map[string]types.String      `tfsdk:"cloud_init_v2"`
+}
+
+type NetworkConfigHost struct {
+	Ip           types.String   `tfsdk:"ip"`
+	Ips          []types.String `tfsdk:"ips"`
+	PrefixLength types.Int64    `tfsdk:"prefix_length"`
+	Gateway      types.String   `tfsdk:"gateway"`
+}
+
+// networkInterface is the static configuration of a single interface.
+type networkInterface struct {
+	Name          string
+	Mac           net.HardwareAddr
+	Prefixes      []netip.Prefix
+	Gateways      []netip.Addr
+	Nameservers   []string
+	SearchDomains []string
+	Mtu           int
+}
+
+type netplanNetwork struct {
+	Version   int                        `yaml:"version"`
+	Ethernets map[string]netplanEthernet `yaml:"ethernets"`
+}
+
+type netplanEthernet struct {
+	Match       *netplanMatch       `yaml:"match,omitempty"`
+	SetName     string              `yaml:"set-name,omitempty"`
+	Dhcp4       bool                `yaml:"dhcp4"`
+	Dhcp6       bool                `yaml:"dhcp6"`
+	Addresses   []string            `yaml:"addresses"`
+	Routes      []netplanRoute      `yaml:"routes,omitempty"`
+	Nameservers *netplanNameservers `yaml:"nameservers,omitempty"`
+	Mtu         int                 `yaml:"mtu,omitempty"`
+}
+
+type netplanMatch struct {
+	Macaddress string `yaml:"macaddress"`
+}
+
+type netplanRoute struct {
+	To     string `yaml:"to"`
+	Via    string `yaml:"via"`
+	OnLink bool   `yaml:"on-link,omitempty"`
+}
+
+type netplanNameservers struct {
+	Addresses []string `yaml:"addresses,omitempty"`
+	Search    []string `yaml:"search,omitempty"`
+}
+
+type cloudInitV1Network struct {
+	Version int                 `yaml:"version"`
+	Config  []cloudInitV1Config `yaml:"config"`
+}
+
+type cloudInitV1Config struct {
+	Type       string              `yaml:"type"`
+	Name       string              `yaml:"name"`
+	MacAddress string              `yaml:"mac_address,omitempty"`
+	Mtu        int                 `yaml:"mtu,omitempty"`
+	Subnets    []cloudInitV1Subnet `yaml:"subnets"`
+}
+
+type cloudInitV1Subnet struct {
+	Type           string   `yaml:"type"`
+	Address        string   `yaml:"address"`
+	Gateway        string   `yaml:"gateway,omitempty"`
+	DnsNameservers []string `yaml:"dns_nameservers,omitempty"`
+	DnsSearch      []string `yaml:"dns_search,omitempty"`
+}
+
+func (d *ipamNetworkConfigDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	d.pools = req.ProviderData.(*providerData).Pools
+}
+
+func (d *ipamNetworkConfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config NetworkConfig
+
+	// Read config
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	pools := make([]*ipam.Pool, 0, len(d.pools))
+	for i := range d.pools {
+		pools = append(pools, ToIpamPool(&d.pools[i]))
+	}
+	interfaces, diags := getNetworkInterfaces(&config, pools)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	config.Netplan = make(map[string]types.String, len(interfaces))
+	config.CloudInitV1 = make(map[string]types.String, len(interfaces))
+	config.CloudInitV2 = make(map[string]types.String, len(interfaces))
+	for h, i := range interfaces {
+		netplan, err := renderNetplan(i, true)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to render netplan configuration", err.Error())
+			return
+		}
+		v1, err := renderCloudInitV1(i)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to render cloud-init configuration", err.Error())
+			return
+		}
+		v2, err := renderNetplan(i, false)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to render cloud-init configuration", err.Error())
+			return
+		}
+		config.Netplan[h] = types.StringValue(netplan)
+		config.CloudInitV1[h] = types.StringValue(v1)
+		config.CloudInitV2[h] = types.StringValue(v2)
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// getNetworkInterfaces returns the interface configuration of all hosts. The
+// prefix length and gateway of a host apply to addresses of the same family
+// as its IP, addresses of the other family use the first pool containing
+// them.
+func getNetworkInterfaces(config *NetworkConfig, pools []*ipam.Pool) (map[string]networkInterface, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	interfaces := make(map[string]networkInterface, len(config.Hosts))
+	for h, host := range config.Hosts {
+		i := networkInterface{Name: config.Interface.ValueString(), Mtu: int(config.Mtu.ValueInt64())}
+		if mac, ok := config.Macs[h]; ok && mac.ValueString() != "" {
+			var err error
+			if i.Mac, err = net.ParseMAC(mac.ValueString()); err != nil {
+				diags.AddError("Invalid MAC address", fmt.Sprintf("MAC address '%s' of '%s' is invalid.", mac.ValueString(), h))
+				return nil, diags
+			}
+		}
+		for _, n := range config.Nameservers {
+			i.Nameservers = append(i.Nameservers, n.ValueString())
+		}
+		for _, s := range config.SearchDomains {
+			i.SearchDomains = append(i.SearchDomains, s.ValueString())
+		}
+
+		hostIp, err := netip.ParseAddr(host.Ip.ValueString())
+		if err != nil {
+			diags.AddError("Invalid IP address", fmt.Sprintf("IP '%s' of '%s' is not a valid address.", host.Ip.ValueString(), h))
+			return nil, diags
+		}
+		if !host.Gateway.IsNull() && host.Gateway.ValueString() != "" {
+			gateway, err := netip.ParseAddr(host.Gateway.ValueString())
+			if err != nil {
+				diags.AddError("Invalid IP address", fmt.Sprintf("Gateway '%s' of '%s' is not a valid address.", host.Gateway.ValueString(), h))
+				return nil, diags
+			}
+			i.Gateways = append(i.Gateways, gateway.Unmap())
+		}
+
+		ips := host.Ips
+		if len(ips) == 0 {
+			ips = []types.String{host.Ip}
+		}
+		for _, ip := range ips {
+			addr, err := netip.ParseAddr(ip.ValueString())
+			if err != nil {
+				diags.AddError("Invalid IP address", fmt.Sprintf("IP '%s' of '%s' is not a valid address.", ip.ValueString(), h))
+				return nil, diags
+			}
+			addr = addr.Unmap()
+			bits := addr.BitLen()
+			if addr.BitLen() == hostIp.Unmap().BitLen() {
+				if !host.PrefixLength.IsNull() {
+					bits = int(host.PrefixLength.ValueInt64())
+				}
+			} else if address, ok := lookupAddress(pools, addr); ok {
+				bits = address.PrefixLength
+				if !i.hasGateway(address.Gateway) {
+					i.Gateways = append(i.Gateways, address.Gateway.Unmap())
+				}
+			}
+			prefix, err := addr.Prefix(bits)
+			if err != nil {
+				diags.AddError("Invalid prefix length", fmt.Sprintf("Prefix length '%d' of '%s' is invalid.", bits, h))
+				return nil, diags
+			}
+			i.Prefixes = append(i.Prefixes, netip.PrefixFrom(addr, prefix.Bits()))
+		}
+		interfaces[h] = i
+	}
+	return interfaces, diags
+}
+
+// lookupAddress returns the address ip of the first pool containing it.
+func lookupAddress(pools []*ipam.Pool, ip netip.Addr) (ipam.Address, bool) {
+	for _, p := range pools {
+		if address, ok := p.Lookup(ip); ok {
+			return address, true
+		}
+	}
+	return ipam.Address{}, false
+}
+
+// hasGateway returns true if the interface has a gateway of the same address
+// family as gateway.
+func (i networkInterface) hasGateway(gateway netip.Addr) bool {
+	for _, g := range i.Gateways {
+		if g.BitLen() == gateway.Unmap().BitLen() {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultRoute returns the destination of the default route of a gateway
+// and whether the gateway is outside of all prefixes of the same family.
+// IPv6 link-local gateways are always reachable on the interface.
+func (i networkInterface) defaultRoute(gateway netip.Addr) (string, bool) {
+	to := "0.0.0.0/0"
+	if gateway.Is6() {
+		to = "::/0"
+		if gateway.IsLinkLocalUnicast() {
+			return to, false
+		}
+	}
+	for _, p := range i.Prefixes {
+		if p.Masked().Contains(gateway) {
+			return to, false
+		}
+	}
+	return to, true
+}
+
+// renderNetplan renders a netplan configuration, or a cloud-init network
+// configuration version 2 without the top-level "network" key.
+func renderNetplan(i networkInterface, netplan bool) (string, error) {
+	e := netplanEthernet{}
+	for _, p := range i.Prefixes {
+		e.Addresses = append(e.Addresses, p.String())
+	}
+	if i.Mac != nil {
+		e.Match = &netplanMatch{Macaddress: i.Mac.String()}
+		e.SetName = i.Name
+	}
+	for _, gateway := range i.Gateways {
+		to, onLink := i.defaultRoute(gateway)
+		e.Routes = append(e.Routes, netplanRoute{To: to, Via: gateway.String(), OnLink: onLink})
+	}
+	if len(i.Nameservers) > 0 || len(i.SearchDomains) > 0 {
+		e.Nameservers = &netplanNameservers{Addresses: i.Nameservers, Search: i.SearchDomains}
+	}
+	e.Mtu = i.Mtu
+
+	var doc interface{} = netplanNetwork{Version: 2, Ethernets: map[string]netplanEthernet{i.Name: e}}
+	if netplan {
+		doc = map[string]interface{}{"network": doc}
+	}
+	return marshalYaml(doc)
+}
+
+// renderCloudInitV1 renders a cloud-init network configuration version 1.
+// Each gateway is added to the first subnet of the same address family.
+func renderCloudInitV1(i networkInterface) (string, error) {
+	c := cloudInitV1Config{Type: "physical", Name: i.Name, Mtu: i.Mtu}
+	if i.Mac != nil {
+		c.MacAddress = i.Mac.String()
+	}
+	gateways := append([]netip.Addr(nil), i.Gateways...)
+	for _, p := range i.Prefixes {
+		s := cloudInitV1Subnet{Type: "static", Address: p.String()}
+		if p.Addr().Is6() {
+			s.Type = "static6"
+		}
+		for j, gateway := range gateways {
+			if p.Addr().Is6() == gateway.Is6() {
+				s.Gateway = gateway.String()
+				gateways = append(gateways[:j], gateways[j+1:]...)
+				break
+			}
+		}
+		c.Subnets = append(c.Subnets, s)
+	}
+	if len(c.Subnets) > 0 {
+		c.Subnets[0].DnsNameservers = i.Nameservers
+		c.Subnets[0].DnsSearch = i.SearchDomains
+	}
+
+	return marshalYaml(cloudInitV1Network{Version: 1, Config: []cloudInitV1Config{c}})
+}
+
+func marshalYaml(doc interface{}) (string, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
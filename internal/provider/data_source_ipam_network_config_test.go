@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+func TestAccIpamNetworkConfig(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamNetworkConfigConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ipam_network_config.test", "netplan.host1", testNetplan),
+					resource.TestCheckResourceAttrSet("data.ipam_network_config.test", "cloud_init_v1.host2"),
+					resource.TestCheckResourceAttrSet("data.ipam_network_config.test", "cloud_init_v2.host2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamNetworkConfigConfig() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+		}
+	}
+
+	data "ipam_network_config" "test" {
+		hosts = {
+			for k, v in ipam_allocate.test.hosts : k => {
+				ip            = v.ip
+				ips           = v.ips
+				prefix_length = v.prefix_length
+				gateway       = v.gateway
+			}
+		}
+		interface   = "ens192"
+		nameservers = ["10.1.0.53"]
+		macs = {
+			"host1" = "00:50:56:00:00:01"
+		}
+	}
+	`
+}
+
+const testNetplan = `network:
+  version: 2
+  ethernets:
+    ens192:
+      match:
+        macaddress: "00:50:56:00:00:01"
+      set-name: ens192
+      dhcp4: false
+      dhcp6: false
+      addresses:
+        - 10.1.0.1/24
+      routes:
+        - to: 0.0.0.0/0
+          via: 10.1.0.254
+      nameservers:
+        addresses:
+          - 10.1.0.53
+`
+
+const testNetplanIpv6 = `version: 2
+ethernets:
+  eth0:
+    dhcp4: false
+    dhcp6: false
+    addresses:
+      - 2001:db8::10/64
+      - 2001:db8::11/64
+    routes:
+      - to: ::/0
+        via: 2001:db8:ffff::1
+        on-link: true
+    mtu: 9000
+`
+
+const testNetplanDualStack = `version: 2
+ethernets:
+  eth0:
+    dhcp4: false
+    dhcp6: false
+    addresses:
+      - 10.0.0.10/24
+      - 2001:db8::10/64
+    routes:
+      - to: 0.0.0.0/0
+        via: 10.0.0.1
+      - to: ::/0
+        via: 2001:db8::1
+`
+
+const testCloudInitV1 = `version: 1
+config:
+  - type: physical
+    name: eth0
+    mtu: 9000
+    subnets:
+      - type: static6
+        address: 2001:db8::10/64
+        gateway: 2001:db8:ffff::1
+        dns_search:
+          - example.com
+      - type: static6
+        address: 2001:db8::11/64
+`
+
+func TestNetworkConfigRender(t *testing.T) {
+	config := NetworkConfig{
+		Hosts: map[string]NetworkConfigHost{
+			"host1": {
+				Ip:           types.StringValue("2001:db8::10"),
+				Ips:          []types.String{types.StringValue("2001:db8::10"), types.StringValue("2001:db8::11")},
+				PrefixLength: types.Int64Value(64),
+				Gateway:      types.StringValue("2001:db8:ffff::1"),
+			},
+		},
+		Interface:     types.StringValue("eth0"),
+		SearchDomains: []types.String{types.StringValue("example.com")},
+		Mtu:           types.Int64Value(9000),
+	}
+	interfaces, diags := getNetworkInterfaces(&config, nil)
+	if diags.HasError() {
+		t.Fatalf("getNetworkInterfaces() error = %v", diags)
+	}
+	i := interfaces["host1"]
+	i.SearchDomains = nil
+	if got, err := renderNetplan(i, false); err != nil || got != testNetplanIpv6 {
+		t.Errorf("renderNetplan() = %s, %v, want %s", got, err, testNetplanIpv6)
+	}
+	if got, err := renderCloudInitV1(interfaces["host1"]); err != nil || got != testCloudInitV1 {
+		t.Errorf("renderCloudInitV1() = %s, %v, want %s", got, err, testCloudInitV1)
+	}
+
+	tests := []struct {
+		gateway string
+		to      string
+		onLink  bool
+	}{
+		{"2001:db8::1", "::/0", false},
+		{"fe80::1", "::/0", false},
+		{"10.0.0.1", "0.0.0.0/0", true},
+	}
+	for _, tt := range tests {
+		config.Hosts["host1"] = NetworkConfigHost{Ip: types.StringValue("2001:db8::10"), PrefixLength: types.Int64Value(64), Gateway: types.StringValue(tt.gateway)}
+		interfaces, _ := getNetworkInterfaces(&config, nil)
+		i := interfaces["host1"]
+		if to, onLink := i.defaultRoute(i.Gateways[0]); to != tt.to || onLink != tt.onLink {
+			t.Errorf("defaultRoute() of %s = %s, %v, want %s, %v", tt.gateway, to, onLink, tt.to, tt.onLink)
+		}
+	}
+
+	config.Hosts["host1"] = NetworkConfigHost{Ip: types.StringValue("10.0.0.1"), PrefixLength: types.Int64Value(33)}
+	if _, diags := getNetworkInterfaces(&config, nil); !diags.HasError() {
+		t.Errorf("getNetworkInterfaces() accepted an invalid prefix length")
+	}
+}
+
+func TestNetworkConfigDualStack(t *testing.T) {
+	config := NetworkConfig{
+		Hosts: map[string]NetworkConfigHost{
+			"host1": {
+				Ip:           types.StringValue("10.0.0.10"),
+				Ips:          []types.String{types.StringValue("10.0.0.10"), types.StringValue("2001:db8::10")},
+				PrefixLength: types.Int64Value(24),
+				Gateway:      types.StringValue("10.0.0.1"),
+			},
+		},
+		Interface: types.StringValue("eth0"),
+	}
+	pools := []*ipam.Pool{{
+		Name:         "POOL6",
+		PrefixLength: 64,
+		Gateway:      netip.MustParseAddr("2001:db8::1"),
+		Ranges:       []ipam.Range{{From: netip.MustParseAddr("2001:db8::10"), To: netip.MustParseAddr("2001:db8::ff")}},
+	}}
+	interfaces, diags := getNetworkInterfaces(&config, pools)
+	if diags.HasError() {
+		t.Fatalf("getNetworkInterfaces() error = %v", diags)
+	}
+	if got, err := renderNetplan(interfaces["host1"], false); err != nil || got != testNetplanDualStack {
+		t.Errorf("renderNetplan() = %s, %v, want %s", got, err, testNetplanDualStack)
+	}
+
+	interfaces, _ = getNetworkInterfaces(&config, nil)
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.10/24"), netip.MustParsePrefix("2001:db8::10/128")}
+	if i := interfaces["host1"]; !reflect.DeepEqual(i.Prefixes, want) || len(i.Gateways) != 1 {
+		t.Errorf("getNetworkInterfaces() without pools = %v, %v, want %v and only the IPv4 gateway", i.Prefixes, i.Gateways, want)
+	}
+}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+var _ datasource.DataSource = (*ipamPoolPrefixesDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*ipamPoolPrefixesDataSource)(nil)
+
+func NewIpamPoolPrefixesDataSource() datasource.DataSource {
+	return &ipamPoolPrefixesDataSource{}
+}
+
+type ipamPoolPrefixesDataSource struct {
+	pools []providerDataPool
+}
+
+func (d *ipamPoolPrefixesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_prefixes"
+}
+
+func (d *ipamPoolPrefixesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Decompose the ranges of a pool into the exact list of prefixes covering them, e.g. `10.0.0.5` to `10.0.0.20` into `10.0.0.5/32`, `10.0.0.6/31`, `10.0.0.8/29`, `10.0.0.16/30` and `10.0.0.20/32`.",
+
+		Attributes: map[string]schema.Attribute{
+			"pool": schema.StringAttribute{
+				MarkdownDescription: "Pool name.",
+				Required:            true,
+			},
+			"prefixes": schema.ListAttribute{
+				MarkdownDescription: "Smallest set of prefixes exactly covering all ranges and addresses of the pool, in ascending order.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"ranges": schema.ListNestedAttribute{
+				MarkdownDescription: "Ranges of the pool in declaration order.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"from_ip": schema.StringAttribute{
+							MarkdownDescription: "First IP address of the range.",
+							Computed:            true,
+						},
+						"to_ip": schema.StringAttribute{
+							MarkdownDescription: "Last IP address of the range.",
+							Computed:            true,
+						},
+						"prefixes": schema.ListAttribute{
+							MarkdownDescription: "Prefixes exactly covering the range, in ascending order.",
+							ElementType:         types.StringType,
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type PoolPrefixes struct {
+	Pool     types.String        `tfsdk:"pool"`
+	Prefixes []types.String      `tfsdk:"prefixes"`
+	Ranges   []PoolPrefixesRange `tfsdk:"ranges"`
+}
+
+type PoolPrefixesRange struct {
+	FromIP   types.String   `tfsdk:"from_ip"`
+	ToIP     types.String   `tfsdk:"to_ip"`
+	Prefixes []types.String `tfsdk:"prefixes"`
+}
+
+func (d *ipamPoolPrefixesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	d.pools = req.ProviderData.(*providerData).Pools
+}
+
+func (d *ipamPoolPrefixesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config PoolPrefixes
+
+	// Read config
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	var pool *providerDataPool
+	for i := range d.pools {
+		if d.pools[i].Name.ValueString() == config.Pool.ValueString() {
+			pool = &d.pools[i]
+		}
+	}
+	if pool == nil {
+		resp.Diagnostics.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", config.Pool.ValueString()))
+		return
+	}
+
+	p := ToIpamPool(pool)
+	var all []netip.Prefix
+	config.Ranges = make([]PoolPrefixesRange, 0, len(p.Ranges))
+	for _, r := range p.Ranges {
+		prefixes := rangePrefixes(r)
+		all = append(all, prefixes...)
+		config.Ranges = append(config.Ranges, PoolPrefixesRange{
+			FromIP:   types.StringValue(r.From.String()),
+			ToIP:     types.StringValue(r.To.String()),
+			Prefixes: prefixStrings(prefixes),
+		})
+	}
+	for _, a := range p.Addresses {
+		all = append(all, netip.PrefixFrom(a.IP, a.IP.BitLen()))
+	}
+	config.Prefixes = prefixStrings(ipam.Summarize(all, 0))
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+}
+
+func prefixStrings(prefixes []netip.Prefix) []types.String {
+	result := make([]types.String, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		result = append(result, types.StringValue(prefix.String()))
+	}
+	return result
+}
+
+// rangePrefixes returns the prefixes exactly covering the addresses of a
+// range, which are only expanded if the range has a step or alignment.
+func rangePrefixes(r ipam.Range) []netip.Prefix {
+	if r.Step <= 1 && r.Align <= 1 {
+		return ipam.RangePrefixes(r.From, r.To)
+	}
+	addresses := (&ipam.Pool{Ranges: []ipam.Range{r}}).Expand()
+	prefixes := make([]netip.Prefix, 0, len(addresses))
+	for _, a := range addresses {
+		prefixes = append(prefixes, netip.PrefixFrom(a.IP, a.IP.BitLen()))
+	}
+	return ipam.Summarize(prefixes, 0)
+}
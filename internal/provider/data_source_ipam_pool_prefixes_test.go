@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+func TestAccIpamPoolPrefixes(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamPoolPrefixesConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool1", "prefixes.#", "3"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool1", "prefixes.0", "1.1.1.1/32"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool1", "prefixes.1", "1.1.1.2/32"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool1", "prefixes.2", "1.1.1.10/31"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.from_ip", "10.1.0.1"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.to_ip", "10.1.0.15"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.prefixes.#", "4"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.prefixes.0", "10.1.0.1/32"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.prefixes.1", "10.1.0.2/31"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.prefixes.2", "10.1.0.4/30"),
+					resource.TestCheckResourceAttr("data.ipam_pool_prefixes.pool2", "ranges.0.prefixes.3", "10.1.0.8/29"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamPoolPrefixesConfig() string {
+	return `
+	data "ipam_pool_prefixes" "pool1" {
+		pool = "POOL1"
+	}
+
+	data "ipam_pool_prefixes" "pool2" {
+		pool = "POOL2"
+	}
+	`
+}
+
+func TestRangePrefixes(t *testing.T) {
+	tests := []struct {
+		r    ipam.Range
+		want []string
+	}{
+		{ipam.Range{From: netip.MustParseAddr("10.0.0.5"), To: netip.MustParseAddr("10.0.0.8")}, []string{"10.0.0.5/32", "10.0.0.6/31", "10.0.0.8/32"}},
+		{ipam.Range{From: netip.MustParseAddr("10.0.0.1"), To: netip.MustParseAddr("10.0.0.12"), Step: 4, Align: 4}, []string{"10.0.0.4/32", "10.0.0.8/32", "10.0.0.12/32"}},
+		{ipam.Range{From: netip.MustParseAddr("10.0.0.1"), To: netip.MustParseAddr("10.0.0.7"), Align: 4}, []string{"10.0.0.4/30"}},
+	}
+	for _, tt := range tests {
+		got := make([]string, 0)
+		for _, p := range rangePrefixes(tt.r) {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rangePrefixes(%v) = %v, want %v", tt.r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+var _ datasource.DataSource = (*ipamPrefixSummaryDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*ipamPrefixSummaryDataSource)(nil)
+
+func NewIpamPrefixSummaryDataSource() datasource.DataSource {
+	return &ipamPrefixSummaryDataSource{}
+}
+
+type ipamPrefixSummaryDataSource struct {
+	pools []providerDataPool
+}
+
+func (d *ipamPrefixSummaryDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_prefix_summary"
+}
+
+func (d *ipamPrefixSummaryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Summarize addresses and prefixes into the smallest set of covering prefixes, e.g. to build prefix lists or firewall object groups for the loopbacks allocated by an `ipam_allocate` resource.",
+
+		Attributes: map[string]schema.Attribute{
+			"ips": schema.ListAttribute{
+				MarkdownDescription: "IP addresses or prefixes, e.g. `[for h in ipam_allocate.example.hosts : h.ip]`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"pool": schema.StringAttribute{
+				MarkdownDescription: "Pool name. All ranges and addresses of the pool are summarized together with `ips`.",
+				Optional:            true,
+			},
+			"max_overcoverage": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of additional addresses the prefixes may cover in total. Prefixes are merged into larger prefixes as long as the limit is not exceeded, preferring merges which cover the fewest additional addresses. Defaults to `0`, which covers the addresses exactly.",
+				Optional:            true,
+			},
+			"prefixes": schema.ListAttribute{
+				MarkdownDescription: "Covering prefixes in ascending order, IPv4 before IPv6.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+type PrefixSummary struct {
+	Ips             []types.String `tfsdk:"ips"`
+	Pool            types.String   `tfsdk:"pool"`
+	MaxOvercoverage types.Int64    `tfsdk:"max_overcoverage"`
+	Prefixes        []types.String `tfsdk:"prefixes"`
+}
+
+func (d *ipamPrefixSummaryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	d.pools = req.ProviderData.(*providerData).Pools
+}
+
+func (d *ipamPrefixSummaryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config PrefixSummary
+
+	// Read config
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	if config.MaxOvercoverage.ValueInt64() < 0 {
+		resp.Diagnostics.AddError("Invalid 'max_overcoverage' configured.", "'max_overcoverage' must not be negative.")
+		return
+	}
+
+	var prefixes []netip.Prefix
+	for _, ip := range config.Ips {
+		prefix, err := parseIPOrPrefix(ip.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid IP address", fmt.Sprintf("'%s' is not a valid IP address or prefix.", ip.ValueString()))
+			return
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	if !config.Pool.IsNull() {
+		var pool *providerDataPool
+		for i := range d.pools {
+			if d.pools[i].Name.ValueString() == config.Pool.ValueString() {
+				pool = &d.pools[i]
+			}
+		}
+		if pool == nil {
+			resp.Diagnostics.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", config.Pool.ValueString()))
+			return
+		}
+		p := ToIpamPool(pool)
+		for _, r := range p.Ranges {
+			prefixes = append(prefixes, rangePrefixes(r)...)
+		}
+		for _, a := range p.Addresses {
+			prefixes = append(prefixes, netip.PrefixFrom(a.IP, a.IP.BitLen()))
+		}
+	}
+
+	config.Prefixes = prefixStrings(ipam.Summarize(prefixes, uint64(config.MaxOvercoverage.ValueInt64())))
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// parseIPOrPrefix parses an IP address as host prefix or a prefix.
+func parseIPOrPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamPrefixSummary(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamPrefixSummaryConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ipam_prefix_summary.hosts", "prefixes.#", "2"),
+					resource.TestCheckResourceAttr("data.ipam_prefix_summary.hosts", "prefixes.0", "10.1.0.1/32"),
+					resource.TestCheckResourceAttr("data.ipam_prefix_summary.hosts", "prefixes.1", "10.1.0.2/31"),
+					resource.TestCheckResourceAttr("data.ipam_prefix_summary.pool", "prefixes.#", "2"),
+					resource.TestCheckResourceAttr("data.ipam_prefix_summary.pool", "prefixes.0", "1.1.1.0/30"),
+					resource.TestCheckResourceAttr("data.ipam_prefix_summary.pool", "prefixes.1", "1.1.1.10/31"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamPrefixSummaryConfig() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+			"host3" = {}
+		}
+	}
+
+	data "ipam_prefix_summary" "hosts" {
+		ips = [for h in ipam_allocate.test.hosts : h.ip]
+	}
+
+	data "ipam_prefix_summary" "pool" {
+		pool             = "POOL1"
+		max_overcoverage = 2
+	}
+	`
+}
+
+func TestParseIPOrPrefix(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{s: "10.0.0.1", want: "10.0.0.1/32"},
+		{s: "10.0.0.0/24", want: "10.0.0.0/24"},
+		{s: "2001:db8::1", want: "2001:db8::1/128"},
+		{s: "10.0.0.256", wantErr: true},
+		{s: "10.0.0.0/33", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseIPOrPrefix(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIPOrPrefix(%s) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.String() != tt.want {
+			t.Errorf("parseIPOrPrefix(%s) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// dnsRecord is a single resource record. Names and values are fully
+// qualified with a trailing dot, zones are not.
+type dnsRecord struct {
+	Zone  string
+	Name  string
+	Type  string
+	Value string
+}
+
+// dnsHost is a host with all its addresses.
+type dnsHost struct {
+	Name         string
+	IPs          []netip.Addr
+	PrefixLength int
+}
+
+// fqdn returns name with a trailing dot.
+func fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// reverseName returns the in-addr.arpa or ip6.arpa name of an address.
+func reverseName(ip netip.Addr) string {
+	ip = ip.Unmap()
+	labels := make([]string, 0, 32)
+	if ip.Is4() {
+		b := ip.As4()
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprint(b[i]))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa."
+	}
+	b := ip.As16()
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, fmt.Sprintf("%x", b[i]&0xf), fmt.Sprintf("%x", b[i]>>4))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa."
+}
+
+// reverseZone returns the reverse zone of an address in a subnet with the
+// given prefix length. Zones are cut at the next octet (IPv4) or nibble
+// (IPv6) boundary, with at most /24 for IPv4 and /124 for IPv6. A zero prefix
+// length uses /24 for IPv4 and /64 for IPv6.
+func reverseZone(ip netip.Addr, prefixLength int) string {
+	ip = ip.Unmap()
+	step, maxBits, bits := 8, 24, 24
+	if ip.Is6() {
+		step, maxBits, bits = 4, 124, 64
+	}
+	if prefixLength > 0 {
+		bits = min((prefixLength+step-1)/step*step, maxBits)
+	}
+	labels := strings.Split(strings.TrimSuffix(reverseName(ip), "."), ".")
+	hostLabels := (ip.BitLen() - bits) / step
+	return strings.Join(labels[hostLabels:], ".") + "."
+}
+
+// classlessZone returns the RFC 2317 zone of an IPv4 address in a subnet
+// longer than /24, e.g. '0/26.2.0.192.in-addr.arpa.'.
+func classlessZone(ip netip.Addr, prefixLength int) (string, bool) {
+	ip = ip.Unmap()
+	if !ip.Is4() || prefixLength <= 24 || prefixLength > 32 {
+		return "", false
+	}
+	network := netip.PrefixFrom(ip, prefixLength).Masked().Addr().As4()
+	return fmt.Sprintf("%d/%d.%s", network[3], prefixLength, reverseZone(ip, 24)), true
+}
+
+// getDnsRecords returns the forward and reverse records of all hosts, sorted
+// by host name. If classless is set, PTR records of IPv4 subnets longer than
+// /24 are placed in RFC 2317 zones and CNAME records are added to the parent
+// zone.
+func getDnsRecords(hosts []dnsHost, zone string, classless bool) []dnsRecord {
+	zone = fqdn(zone)
+	hosts = append([]dnsHost(nil), hosts...)
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+
+	records := make([]dnsRecord, 0)
+	for _, h := range hosts {
+		name := fqdn(h.Name + "." + strings.TrimSuffix(zone, "."))
+		for _, ip := range h.IPs {
+			ip = ip.Unmap()
+			recordType := "A"
+			if ip.Is6() {
+				recordType = "AAAA"
+			}
+			records = append(records, dnsRecord{Zone: strings.TrimSuffix(zone, "."), Name: name, Type: recordType, Value: ip.String()})
+		}
+		for _, ip := range h.IPs {
+			ptr := reverseName(ip)
+			reverse := reverseZone(ip, h.PrefixLength)
+			if cz, ok := classlessZone(ip, h.PrefixLength); ok && classless {
+				delegated := strings.SplitN(ptr, ".", 2)[0] + "." + cz
+				records = append(records, dnsRecord{Zone: strings.TrimSuffix(reverse, "."), Name: ptr, Type: "CNAME", Value: delegated})
+				ptr, reverse = delegated, cz
+			}
+			records = append(records, dnsRecord{Zone: strings.TrimSuffix(reverse, "."), Name: ptr, Type: "PTR", Value: name})
+		}
+	}
+	return records
+}
+
+// renderZoneFiles renders the records of every zone as BIND zone file
+// snippets. A zero ttl omits the TTL field.
+func renderZoneFiles(records []dnsRecord, ttl int64) map[string]string {
+	zones := make(map[string]*strings.Builder)
+	for _, r := range records {
+		b, ok := zones[r.Zone]
+		if !ok {
+			b = &strings.Builder{}
+			zones[r.Zone] = b
+		}
+		if ttl > 0 {
+			fmt.Fprintf(b, "%s %d IN %s %s\n", r.Name, ttl, r.Type, r.Value)
+		} else {
+			fmt.Fprintf(b, "%s IN %s %s\n", r.Name, r.Type, r.Value)
+		}
+	}
+	files := make(map[string]string, len(zones))
+	for zone, b := range zones {
+		files[zone] = b.String()
+	}
+	return files
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"::ffff:192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range tests {
+		if got := reverseName(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("reverseName(%s) = %s, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestReverseZone(t *testing.T) {
+	tests := []struct {
+		ip           string
+		prefixLength int
+		want         string
+	}{
+		{"192.0.2.1", 0, "2.0.192.in-addr.arpa."},
+		{"192.0.2.1", 24, "2.0.192.in-addr.arpa."},
+		{"192.0.2.1", 26, "2.0.192.in-addr.arpa."},
+		{"192.0.2.1", 22, "2.0.192.in-addr.arpa."},
+		{"10.1.2.3", 16, "1.10.in-addr.arpa."},
+		{"10.1.2.3", 8, "10.in-addr.arpa."},
+		{"2001:db8::1", 0, "0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"2001:db8::1", 48, "0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"2001:db8::1", 127, "0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range tests {
+		if got := reverseZone(netip.MustParseAddr(tt.ip), tt.prefixLength); got != tt.want {
+			t.Errorf("reverseZone(%s, %d) = %s, want %s", tt.ip, tt.prefixLength, got, tt.want)
+		}
+	}
+}
+
+func TestClasslessZone(t *testing.T) {
+	tests := []struct {
+		ip           string
+		prefixLength int
+		want         string
+		ok           bool
+	}{
+		{"192.0.2.1", 24, "", false},
+		{"192.0.2.1", 26, "0/26.2.0.192.in-addr.arpa.", true},
+		{"192.0.2.200", 27, "192/27.2.0.192.in-addr.arpa.", true},
+		{"2001:db8::1", 126, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := classlessZone(netip.MustParseAddr(tt.ip), tt.prefixLength)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("classlessZone(%s, %d) = %s, %v, want %s, %v", tt.ip, tt.prefixLength, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGetDnsRecords(t *testing.T) {
+	hosts := []dnsHost{
+		{Name: "host2", IPs: []netip.Addr{netip.MustParseAddr("2001:db8::2")}, PrefixLength: 64},
+		{Name: "host1", IPs: []netip.Addr{netip.MustParseAddr("192.0.2.65")}, PrefixLength: 26},
+	}
+	want := []dnsRecord{
+		{Zone: "example.com", Name: "host1.example.com.", Type: "A", Value: "192.0.2.65"},
+		{Zone: "2.0.192.in-addr.arpa", Name: "65.2.0.192.in-addr.arpa.", Type: "CNAME", Value: "65.64/26.2.0.192.in-addr.arpa."},
+		{Zone: "64/26.2.0.192.in-addr.arpa", Name: "65.64/26.2.0.192.in-addr.arpa.", Type: "PTR", Value: "host1.example.com."},
+		{Zone: "example.com", Name: "host2.example.com.", Type: "AAAA", Value: "2001:db8::2"},
+		{Zone: "0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", Name: "2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", Type: "PTR", Value: "host2.example.com."},
+	}
+	if got := getDnsRecords(hosts, "example.com.", true); !reflect.DeepEqual(got, want) {
+		t.Errorf("getDnsRecords() = %v, want %v", got, want)
+	}
+
+	got := getDnsRecords(hosts[1:], "example.com", false)
+	if len(got) != 2 || got[1].Type != "PTR" || got[1].Zone != "2.0.192.in-addr.arpa" || got[1].Name != "65.2.0.192.in-addr.arpa." {
+		t.Errorf("getDnsRecords() = %v", got)
+	}
+}
+
+func TestRenderZoneFiles(t *testing.T) {
+	records := []dnsRecord{
+		{Zone: "example.com", Name: "host1.example.com.", Type: "A", Value: "192.0.2.1"},
+		{Zone: "2.0.192.in-addr.arpa", Name: "1.2.0.192.in-addr.arpa.", Type: "PTR", Value: "host1.example.com."},
+		{Zone: "example.com", Name: "host2.example.com.", Type: "A", Value: "192.0.2.2"},
+	}
+	want := map[string]string{
+		"example.com":          "host1.example.com. 300 IN A 192.0.2.1\nhost2.example.com. 300 IN A 192.0.2.2\n",
+		"2.0.192.in-addr.arpa": "1.2.0.192.in-addr.arpa. 300 IN PTR host1.example.com.\n",
+	}
+	if got := renderZoneFiles(records, 300); !reflect.DeepEqual(got, want) {
+		t.Errorf("renderZoneFiles() = %v, want %v", got, want)
+	}
+	if got := renderZoneFiles(records[:1], 0); got["example.com"] != "host1.example.com. IN A 192.0.2.1\n" {
+		t.Errorf("renderZoneFiles() = %v", got)
+	}
+}
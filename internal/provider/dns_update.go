@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var dnsTsigAlgorithms = map[string]string{
+	"hmac-md5":    dns.HmacMD5,
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// dnsUpdater sends RFC 2136 dynamic updates, optionally signed with TSIG.
+type dnsUpdater struct {
+	server    string
+	transport string
+	keyName   string
+	algorithm string
+	secret    string
+	onFailure string
+	timeout   time.Duration
+}
+
+// dnsRRset identifies all records of a name and type.
+type dnsRRset struct {
+	Zone string
+	Name string
+	Type string
+}
+
+// dnsRecordSet is the TTL and the values of all records of a record set.
+type dnsRecordSet struct {
+	TTL    int64
+	Values []string
+}
+
+func newDnsUpdater(config *providerDataDnsUpdate) (*dnsUpdater, error) {
+	u := &dnsUpdater{
+		server:    config.Server.ValueString(),
+		transport: "udp",
+		algorithm: dns.HmacSHA256,
+		onFailure: "warn",
+		timeout:   5 * time.Second,
+	}
+	if _, _, err := net.SplitHostPort(u.server); err != nil {
+		u.server = net.JoinHostPort(u.server, "53")
+	}
+	if !config.Transport.IsNull() {
+		u.transport = config.Transport.ValueString()
+		if u.transport != "udp" && u.transport != "tcp" {
+			return nil, fmt.Errorf("'transport' must be either 'udp' or 'tcp'")
+		}
+	}
+	if !config.TsigKeyName.IsNull() {
+		u.keyName = dns.Fqdn(config.TsigKeyName.ValueString())
+		u.secret = config.TsigSecret.ValueString()
+		if u.secret == "" {
+			return nil, fmt.Errorf("'tsig_secret' must be configured together with 'tsig_key_name'")
+		}
+	}
+	if !config.TsigAlgorithm.IsNull() {
+		algorithm, ok := dnsTsigAlgorithms[strings.TrimSuffix(strings.ToLower(config.TsigAlgorithm.ValueString()), ".")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TSIG algorithm '%s'", config.TsigAlgorithm.ValueString())
+		}
+		u.algorithm = algorithm
+	}
+	if !config.OnFailure.IsNull() {
+		u.onFailure = config.OnFailure.ValueString()
+		if u.onFailure != "warn" && u.onFailure != "error" {
+			return nil, fmt.Errorf("'on_failure' must be either 'warn' or 'error'")
+		}
+	}
+	return u, nil
+}
+
+// dnsPoolRRsets returns the A/AAAA and PTR record sets of the hosts of a pool.
+func dnsPoolRRsets(pool *providerDataPool, hosts []dnsHost) map[dnsRRset]dnsRecordSet {
+	var ttl int64 = 3600
+	if !pool.DnsTtl.IsNull() {
+		ttl = pool.DnsTtl.ValueInt64()
+	}
+	template := "{host}"
+	if !pool.DnsNameTemplate.IsNull() {
+		template = pool.DnsNameTemplate.ValueString()
+	}
+	named := make([]dnsHost, 0, len(hosts))
+	for _, h := range hosts {
+		h.Name = strings.ReplaceAll(template, "{host}", h.Name)
+		named = append(named, h)
+	}
+
+	rrsets := make(map[dnsRRset]dnsRecordSet)
+	for _, r := range getDnsRecords(named, pool.DnsZone.ValueString(), false) {
+		if r.Type == "PTR" && !pool.DnsReverseZone.IsNull() {
+			r.Zone = strings.TrimSuffix(pool.DnsReverseZone.ValueString(), ".")
+		}
+		key := dnsRRset{Zone: r.Zone, Name: r.Name, Type: r.Type}
+		rrsets[key] = dnsRecordSet{TTL: ttl, Values: append(rrsets[key].Values, r.Value)}
+	}
+	return rrsets
+}
+
+// getDnsUpdates returns one update message per zone which replaces all record
+// sets that differ between before and after.
+func getDnsUpdates(before, after map[dnsRRset]dnsRecordSet) ([]*dns.Msg, error) {
+	keys := make([]dnsRRset, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Zone != keys[j].Zone {
+			return keys[i].Zone < keys[j].Zone
+		}
+		if keys[i].Name != keys[j].Name {
+			return keys[i].Name < keys[j].Name
+		}
+		return keys[i].Type < keys[j].Type
+	})
+
+	updates := make([]*dns.Msg, 0)
+	zones := make(map[string]*dns.Msg)
+	for _, k := range keys {
+		if before[k].TTL == after[k].TTL && equalStrings(before[k].Values, after[k].Values) {
+			continue
+		}
+		m, ok := zones[k.Zone]
+		if !ok {
+			m = new(dns.Msg)
+			m.SetUpdate(dns.Fqdn(k.Zone))
+			zones[k.Zone] = m
+			updates = append(updates, m)
+		}
+		m.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: k.Name, Rrtype: dns.StringToType[k.Type], Class: dns.ClassINET}}})
+		rrs := make([]dns.RR, 0, len(after[k].Values))
+		for _, value := range after[k].Values {
+			if k.Type == "PTR" || k.Type == "CNAME" {
+				value = dns.Fqdn(value)
+			}
+			rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", k.Name, after[k].TTL, k.Type, value))
+			if err != nil {
+				return nil, err
+			}
+			rrs = append(rrs, rr)
+		}
+		if len(rrs) > 0 {
+			m.Insert(rrs)
+		}
+	}
+	return updates, nil
+}
+
+// Send sends all updates and returns the errors of all failed updates.
+func (u *dnsUpdater) Send(ctx context.Context, updates []*dns.Msg) error {
+	client := &dns.Client{Net: u.transport, Timeout: u.timeout}
+	if u.keyName != "" {
+		client.TsigSecret = map[string]string{u.keyName: u.secret}
+	}
+	var errs []error
+	for _, m := range updates {
+		zone := m.Question[0].Name
+		if u.keyName != "" {
+			m.SetTsig(u.keyName, u.algorithm, 300, time.Now().Unix())
+		}
+		resp, _, err := client.ExchangeContext(ctx, m, u.server)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("update of zone '%s' failed: %w", zone, err))
+			continue
+		}
+		if resp.Rcode != dns.RcodeSuccess {
+			errs = append(errs, fmt.Errorf("update of zone '%s' failed: %s", zone, dns.RcodeToString[resp.Rcode]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dnsHostsFromAllocations returns the hosts of an allocation with all
+// their addresses.
+func dnsHostsFromAllocations(ctx context.Context, hosts map[string]AllocateHost) []dnsHost {
+	result := make([]dnsHost, 0, len(hosts))
+	for h, a := range hosts {
+		var addresses []string
+		if !a.Ips.IsNull() && !a.Ips.IsUnknown() {
+			a.Ips.ElementsAs(ctx, &addresses, false)
+		} else if a.Ip.ValueString() != "" {
+			addresses = []string{a.Ip.ValueString()}
+		}
+		host := dnsHost{Name: h, PrefixLength: int(a.PrefixLength.ValueInt64())}
+		for _, address := range addresses {
+			if ip, err := netip.ParseAddr(address); err == nil {
+				host.IPs = append(host.IPs, ip)
+			}
+		}
+		result = append(result, host)
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/miekg/dns"
+)
+
+const testTsigSecret = "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+
+// testDnsServer is an in-process DNS server which accepts updates signed
+// with the key "test-key." and records them as strings.
+type testDnsServer struct {
+	addr    string
+	mu      sync.Mutex
+	updates []string
+}
+
+func startTestDnsServer(t *testing.T) *testDnsServer {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	s := &testDnsServer{addr: pc.LocalAddr().String()}
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		TsigSecret:        map[string]string{"test-key.": testTsigSecret},
+		NotifyStartedFunc: func() { close(started) },
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			if int(dh.Bits>>11)&0xF == dns.OpcodeUpdate {
+				return dns.MsgAccept
+			}
+			return dns.DefaultMsgAcceptFunc(dh)
+		},
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			if r.IsTsig() == nil || w.TsigStatus() != nil {
+				m.Rcode = dns.RcodeRefused
+				w.WriteMsg(m)
+				return
+			}
+			s.mu.Lock()
+			for _, rr := range r.Ns {
+				s.updates = append(s.updates, strings.Join(strings.Fields(rr.String()), " "))
+			}
+			s.mu.Unlock()
+			m.SetTsig(r.IsTsig().Hdr.Name, r.IsTsig().Algorithm, 300, time.Now().Unix())
+			w.WriteMsg(m)
+		}),
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return s
+}
+
+func (s *testDnsServer) Updates() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.updates...)
+}
+
+func TestNewDnsUpdater(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  providerDataDnsUpdate
+		server  string
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: providerDataDnsUpdate{Server: types.StringValue("192.0.2.53")},
+			server: "192.0.2.53:53",
+		},
+		{
+			name:   "port",
+			config: providerDataDnsUpdate{Server: types.StringValue("[2001:db8::53]:5353"), TsigKeyName: types.StringValue("key"), TsigSecret: types.StringValue(testTsigSecret)},
+			server: "[2001:db8::53]:5353",
+		},
+		{
+			name:    "invalid transport",
+			config:  providerDataDnsUpdate{Server: types.StringValue("192.0.2.53"), Transport: types.StringValue("tls")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid algorithm",
+			config:  providerDataDnsUpdate{Server: types.StringValue("192.0.2.53"), TsigAlgorithm: types.StringValue("hmac-sha3")},
+			wantErr: true,
+		},
+		{
+			name:    "missing secret",
+			config:  providerDataDnsUpdate{Server: types.StringValue("192.0.2.53"), TsigKeyName: types.StringValue("key")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid on_failure",
+			config:  providerDataDnsUpdate{Server: types.StringValue("192.0.2.53"), OnFailure: types.StringValue("ignore")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDnsUpdater(&tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("newDnsUpdater() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newDnsUpdater() error = %v", err)
+			}
+			if got.server != tt.server {
+				t.Errorf("newDnsUpdater() server = %s, want %s", got.server, tt.server)
+			}
+		})
+	}
+}
+
+func TestGetDnsUpdates(t *testing.T) {
+	pool := &providerDataPool{DnsZone: types.StringValue("example.com"), DnsNameTemplate: types.StringValue("{host}-mgmt"), DnsReverseZone: types.StringNull(), DnsTtl: types.Int64Value(300)}
+	before := dnsPoolRRsets(pool, []dnsHost{
+		{Name: "a", IPs: []netip.Addr{netip.MustParseAddr("192.0.2.1")}, PrefixLength: 24},
+		{Name: "b", IPs: []netip.Addr{netip.MustParseAddr("192.0.2.2")}, PrefixLength: 24},
+	})
+	after := dnsPoolRRsets(pool, []dnsHost{
+		{Name: "a", IPs: []netip.Addr{netip.MustParseAddr("192.0.2.1")}, PrefixLength: 24},
+		{Name: "c", IPs: []netip.Addr{netip.MustParseAddr("192.0.2.3")}, PrefixLength: 24},
+	})
+
+	updates, err := getDnsUpdates(before, after)
+	if err != nil {
+		t.Fatalf("getDnsUpdates() error = %v", err)
+	}
+	var got []string
+	for _, m := range updates {
+		for _, rr := range m.Ns {
+			got = append(got, m.Question[0].Name+" "+strings.Join(strings.Fields(rr.String()), " "))
+		}
+	}
+	want := []string{
+		"2.0.192.in-addr.arpa. 2.2.0.192.in-addr.arpa. 0 CLASS255 PTR",
+		"2.0.192.in-addr.arpa. 3.2.0.192.in-addr.arpa. 0 CLASS255 PTR",
+		"2.0.192.in-addr.arpa. 3.2.0.192.in-addr.arpa. 300 IN PTR c-mgmt.example.com.",
+		"example.com. b-mgmt.example.com. 0 CLASS255 A",
+		"example.com. c-mgmt.example.com. 0 CLASS255 A",
+		"example.com. c-mgmt.example.com. 300 IN A 192.0.2.3",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getDnsUpdates() = %v, want %v", got, want)
+	}
+
+	updates, err = getDnsUpdates(after, after)
+	if err != nil || len(updates) != 0 {
+		t.Errorf("getDnsUpdates() = %v, %v, want no updates", updates, err)
+	}
+}
+
+func TestDnsRRsetsOverflow(t *testing.T) {
+	r := &ipamAllocateResource{pools: []providerDataPool{
+		{Name: types.StringValue("POOL1"), DnsZone: types.StringValue("example.com"), DnsNameTemplate: types.StringNull(), DnsReverseZone: types.StringNull(), DnsTtl: types.Int64Null()},
+		{Name: types.StringValue("POOL2"), DnsZone: types.StringValue("overflow.example.com"), DnsNameTemplate: types.StringNull(), DnsReverseZone: types.StringNull(), DnsTtl: types.Int64Value(60)},
+	}}
+	a := &Allocate{Pool: types.StringValue("POOL1"), Hosts: map[string]AllocateHost{
+		"a": {Ip: types.StringValue("192.0.2.1"), Ips: types.ListNull(types.StringType), Pool: types.StringValue("POOL1")},
+		"b": {Ip: types.StringValue("198.51.100.1"), Ips: types.ListNull(types.StringType), Pool: types.StringValue("POOL2")},
+	}}
+	got := r.dnsRRsets(context.Background(), a)
+	want := map[dnsRRset]dnsRecordSet{
+		{Zone: "example.com", Name: "a.example.com.", Type: "A"}:                           {TTL: 3600, Values: []string{"192.0.2.1"}},
+		{Zone: "2.0.192.in-addr.arpa", Name: "1.2.0.192.in-addr.arpa.", Type: "PTR"}:       {TTL: 3600, Values: []string{"a.example.com."}},
+		{Zone: "overflow.example.com", Name: "b.overflow.example.com.", Type: "A"}:         {TTL: 60, Values: []string{"198.51.100.1"}},
+		{Zone: "100.51.198.in-addr.arpa", Name: "1.100.51.198.in-addr.arpa.", Type: "PTR"}: {TTL: 60, Values: []string{"b.overflow.example.com."}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dnsRRsets() = %v, want %v", got, want)
+	}
+}
+
+func TestDnsUpdaterSend(t *testing.T) {
+	server := startTestDnsServer(t)
+	pool := &providerDataPool{DnsZone: types.StringValue("example.com"), DnsNameTemplate: types.StringNull(), DnsReverseZone: types.StringValue("2.0.192.in-addr.arpa"), DnsTtl: types.Int64Value(60)}
+	after := dnsPoolRRsets(pool, []dnsHost{{Name: "a", IPs: []netip.Addr{netip.MustParseAddr("192.0.2.1")}}})
+
+	updates, err := getDnsUpdates(nil, after)
+	if err != nil {
+		t.Fatalf("getDnsUpdates() error = %v", err)
+	}
+	u, err := newDnsUpdater(&providerDataDnsUpdate{
+		Server:      types.StringValue(server.addr),
+		TsigKeyName: types.StringValue("test-key"),
+		TsigSecret:  types.StringValue(testTsigSecret),
+	})
+	if err != nil {
+		t.Fatalf("newDnsUpdater() error = %v", err)
+	}
+	if err := u.Send(context.Background(), updates); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	got := server.Updates()
+	sort.Strings(got)
+	want := []string{
+		"1.2.0.192.in-addr.arpa. 0 CLASS255 PTR",
+		"1.2.0.192.in-addr.arpa. 60 IN PTR a.example.com.",
+		"a.example.com. 0 CLASS255 A",
+		"a.example.com. 60 IN A 192.0.2.1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Send() updates = %v, want %v", got, want)
+	}
+
+	u.secret = "d3Jvbmctc2VjcmV0"
+	if err := u.Send(context.Background(), updates); err == nil {
+		t.Errorf("Send() with wrong secret error = nil, want error")
+	}
+	if n := len(server.Updates()); n != len(want) {
+		t.Errorf("Send() with wrong secret applied updates, got %d records", n)
+	}
+}
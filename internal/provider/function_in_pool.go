@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/netip"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+var _ function.Function = (*inPoolFunction)(nil)
+
+func NewInPoolFunction() function.Function {
+	return &inPoolFunction{}
+}
+
+type inPoolFunction struct{}
+
+func (f *inPoolFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "in_pool"
+}
+
+func (f *inPoolFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Check if a pool contains an IP address",
+		MarkdownDescription: "Returns `true` if `ip` is one of the addresses of `pool`, taking `step` and `align` of its ranges into account. Provider functions cannot access the provider configuration, the pool is therefore passed as an object with the same `ranges` and `addresses` attributes as in the provider configuration, e.g. a pool defined in a local value and used for both. Other attributes are ignored.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "pool",
+				MarkdownDescription: "Pool object, e.g. `{ ranges = [{ from_ip = \"10.0.0.10\", to_ip = \"10.0.0.20\", step = 2 }], addresses = [{ ip = \"10.0.0.30\" }] }`.",
+			},
+			function.StringParameter{
+				Name:                "ip",
+				MarkdownDescription: "IP address.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *inPoolFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var pool types.Dynamic
+	var ip string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &pool, &ip))
+	if resp.Error != nil {
+		return
+	}
+
+	addr, err := parseFunctionAddr(1, ip)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+	value, valueErr := pool.UnderlyingValue().ToTerraformValue(ctx)
+	if valueErr != nil {
+		resp.Error = function.NewArgumentFuncError(0, valueErr.Error())
+		return
+	}
+	ranges, addresses, err := parseFunctionPool(value)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+
+	result := false
+	for _, r := range ranges {
+		result = result || r.Contains(addr)
+	}
+	for _, a := range addresses {
+		result = result || a == addr
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, result))
+}
+
+// parseFunctionPool returns the ranges and addresses of a pool object.
+// Addresses can be objects with an 'ip' attribute or strings. Ranges may have
+// 'step' and 'align' attributes.
+func parseFunctionPool(value tftypes.Value) ([]ipam.Range, []netip.Addr, *function.FuncError) {
+	invalid := function.NewArgumentFuncError(0, "'pool' must be an object with 'ranges' and 'addresses' attributes.")
+
+	var attributes map[string]tftypes.Value
+	if value.IsNull() || value.As(&attributes) != nil {
+		return nil, nil, invalid
+	}
+	var ranges, addresses []tftypes.Value
+	if v, ok := attributes["ranges"]; ok && v.As(&ranges) != nil {
+		return nil, nil, invalid
+	}
+	if v, ok := attributes["addresses"]; ok && v.As(&addresses) != nil {
+		return nil, nil, invalid
+	}
+
+	var result []ipam.Range
+	for _, r := range ranges {
+		var fromIp, toIp string
+		if getStringAttribute(r, "from_ip", &fromIp) != nil || getStringAttribute(r, "to_ip", &toIp) != nil {
+			return nil, nil, invalid
+		}
+		parsed, err := parseFunctionRange(0, fromIp, toIp)
+		if err != nil {
+			return nil, nil, function.NewArgumentFuncError(0, fmt.Sprintf("Range '%s' - '%s' of 'pool' is invalid.", fromIp, toIp))
+		}
+		step, stepErr := getOptionalIntAttribute(r, "step")
+		align, alignErr := getOptionalIntAttribute(r, "align")
+		if stepErr != nil || alignErr != nil || step < 0 || align < 0 || align > 1<<32 || align&(align-1) != 0 {
+			return nil, nil, function.NewArgumentFuncError(0, fmt.Sprintf("Range '%s' - '%s' of 'pool' has an invalid 'step' or 'align'.", fromIp, toIp))
+		}
+		result = append(result, ipam.Range{From: parsed.From, To: parsed.To, Step: int(step), Align: int(align)})
+	}
+	var addrs []netip.Addr
+	for _, a := range addresses {
+		var ip string
+		if a.Type().Is(tftypes.String) {
+			if a.As(&ip) != nil {
+				return nil, nil, invalid
+			}
+		} else if getStringAttribute(a, "ip", &ip) != nil {
+			return nil, nil, invalid
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return nil, nil, function.NewArgumentFuncError(0, fmt.Sprintf("Address '%s' of 'pool' is not a valid IP address.", ip))
+		}
+		addrs = append(addrs, addr.Unmap())
+	}
+	return result, addrs, nil
+}
+
+func getStringAttribute(value tftypes.Value, name string, target *string) error {
+	var attributes map[string]tftypes.Value
+	if err := value.As(&attributes); err != nil {
+		return err
+	}
+	v, ok := attributes[name]
+	if !ok {
+		return fmt.Errorf("missing attribute '%s'", name)
+	}
+	return v.As(target)
+}
+
+// getOptionalIntAttribute returns the value of a whole number attribute, or 0
+// if it is missing or null.
+func getOptionalIntAttribute(value tftypes.Value, name string) (int64, error) {
+	var attributes map[string]tftypes.Value
+	if err := value.As(&attributes); err != nil {
+		return 0, err
+	}
+	v, ok := attributes[name]
+	if !ok || v.IsNull() {
+		return 0, nil
+	}
+	var n big.Float
+	if err := v.As(&n); err != nil {
+		return 0, err
+	}
+	i, accuracy := n.Int64()
+	if accuracy != big.Exact {
+		return 0, fmt.Errorf("'%s' is not a whole number", name)
+	}
+	return i, nil
+}
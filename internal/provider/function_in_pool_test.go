@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestInPoolFunction(t *testing.T) {
+	rangeType := types.ObjectType{AttrTypes: map[string]attr.Type{"from_ip": types.StringType, "to_ip": types.StringType}}
+	addressType := types.ObjectType{AttrTypes: map[string]attr.Type{"ip": types.StringType, "gateway": types.StringType}}
+	pool := types.ObjectValueMust(
+		map[string]attr.Type{"name": types.StringType, "ranges": types.ListType{ElemType: rangeType}, "addresses": types.ListType{ElemType: addressType}},
+		map[string]attr.Value{
+			"name": types.StringValue("POOL1"),
+			"ranges": types.ListValueMust(rangeType, []attr.Value{
+				types.ObjectValueMust(rangeType.AttrTypes, map[string]attr.Value{"from_ip": types.StringValue("10.0.0.10"), "to_ip": types.StringValue("10.0.0.20")}),
+			}),
+			"addresses": types.ListValueMust(addressType, []attr.Value{
+				types.ObjectValueMust(addressType.AttrTypes, map[string]attr.Value{"ip": types.StringValue("10.0.0.30"), "gateway": types.StringNull()}),
+			}),
+		},
+	)
+	strings := types.ObjectValueMust(
+		map[string]attr.Type{"addresses": types.TupleType{ElemTypes: []attr.Type{types.StringType}}},
+		map[string]attr.Value{"addresses": types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("2001:db8::1")})},
+	)
+	steppedType := types.ObjectType{AttrTypes: map[string]attr.Type{"from_ip": types.StringType, "to_ip": types.StringType, "step": types.NumberType, "align": types.NumberType}}
+	stepped := types.ObjectValueMust(
+		map[string]attr.Type{"ranges": types.TupleType{ElemTypes: []attr.Type{steppedType}}},
+		map[string]attr.Value{"ranges": types.TupleValueMust([]attr.Type{steppedType}, []attr.Value{
+			types.ObjectValueMust(steppedType.AttrTypes, map[string]attr.Value{"from_ip": types.StringValue("10.0.0.1"), "to_ip": types.StringValue("10.0.0.40"), "step": types.NumberValue(big.NewFloat(8)), "align": types.NumberValue(big.NewFloat(4))}),
+		})},
+	)
+	invalidStep := types.ObjectValueMust(
+		map[string]attr.Type{"ranges": types.TupleType{ElemTypes: []attr.Type{steppedType}}},
+		map[string]attr.Value{"ranges": types.TupleValueMust([]attr.Type{steppedType}, []attr.Value{
+			types.ObjectValueMust(steppedType.AttrTypes, map[string]attr.Value{"from_ip": types.StringValue("10.0.0.1"), "to_ip": types.StringValue("10.0.0.40"), "step": types.NumberNull(), "align": types.NumberValue(big.NewFloat(6))}),
+		})},
+	)
+	invalid := types.ObjectValueMust(
+		map[string]attr.Type{"ranges": types.StringType},
+		map[string]attr.Value{"ranges": types.StringValue("10.0.0.10-10.0.0.20")},
+	)
+
+	tests := []struct {
+		name    string
+		pool    attr.Value
+		ip      string
+		want    bool
+		wantErr bool
+	}{
+		{name: "range", pool: pool, ip: "10.0.0.15", want: true},
+		{name: "address", pool: pool, ip: "10.0.0.30", want: true},
+		{name: "outside", pool: pool, ip: "10.0.0.21", want: false},
+		{name: "address strings", pool: strings, ip: "2001:db8::1", want: true},
+		{name: "aligned step", pool: stepped, ip: "10.0.0.12", want: true},
+		{name: "between steps", pool: stepped, ip: "10.0.0.8", want: false},
+		{name: "before aligned start", pool: stepped, ip: "10.0.0.1", want: false},
+		{name: "invalid align", pool: invalidStep, ip: "10.0.0.4", wantErr: true},
+		{name: "invalid pool", pool: invalid, ip: "10.0.0.15", wantErr: true},
+		{name: "not an object", pool: types.StringValue("POOL1"), ip: "10.0.0.15", wantErr: true},
+		{name: "invalid ip", pool: pool, ip: "10.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := runTestFunction(t, NewInPoolFunction(), types.DynamicValue(tt.pool), types.StringValue(tt.ip))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("in_pool(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(types.BoolValue(tt.want)) {
+			t.Errorf("in_pool(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*ipAddFunction)(nil)
+
+func NewIpAddFunction() function.Function {
+	return &ipAddFunction{}
+}
+
+type ipAddFunction struct{}
+
+func (f *ipAddFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "ip_add"
+}
+
+func (f *ipAddFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Add an offset to an IP address",
+		MarkdownDescription: "Returns the IP address `n` addresses after `ip`, or before `ip` if `n` is negative. Unlike `cidrhost` the result is not limited to a prefix, e.g. `ip_add(\"10.0.0.255\", 1)` returns `10.0.1.0`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "ip",
+				MarkdownDescription: "IP address.",
+			},
+			function.Int64Parameter{
+				Name:                "n",
+				MarkdownDescription: "Offset.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *ipAddFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var ip string
+	var n int64
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &ip, &n))
+	if resp.Error != nil {
+		return
+	}
+
+	addr, err := parseFunctionAddr(0, ip)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+	result, ok := intToAddr(new(big.Int).Add(addrToInt(addr), big.NewInt(n)), addr.BitLen())
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Adding %d to '%s' exceeds the IP address space.", n, ip))
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, result.String()))
+}
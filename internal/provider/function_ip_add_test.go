@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestIpAddFunction(t *testing.T) {
+	tests := []struct {
+		ip      string
+		n       int64
+		want    string
+		wantErr bool
+	}{
+		{ip: "10.0.0.255", n: 1, want: "10.0.1.0"},
+		{ip: "10.0.1.0", n: -1, want: "10.0.0.255"},
+		{ip: "::ffff:10.0.0.1", n: 0, want: "10.0.0.1"},
+		{ip: "2001:db8::ffff", n: 65537, want: "2001:db8::2:0"},
+		{ip: "255.255.255.255", n: 1, wantErr: true},
+		{ip: "0.0.0.0", n: -1, wantErr: true},
+		{ip: "10.0.0", n: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := runTestFunction(t, NewIpAddFunction(), types.StringValue(tt.ip), types.Int64Value(tt.n))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ip_add(%s, %d) error = %v, wantErr %v", tt.ip, tt.n, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(types.StringValue(tt.want)) {
+			t.Errorf("ip_add(%s, %d) = %v, want %s", tt.ip, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*ipDiffFunction)(nil)
+
+func NewIpDiffFunction() function.Function {
+	return &ipDiffFunction{}
+}
+
+type ipDiffFunction struct{}
+
+func (f *ipDiffFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "ip_diff"
+}
+
+func (f *ipDiffFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Subtract two IP addresses",
+		MarkdownDescription: "Returns the number of addresses from `b` to `a`, which is negative if `a` is smaller than `b`. `ip_add(b, ip_diff(a, b))` returns `a`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "a",
+				MarkdownDescription: "IP address.",
+			},
+			function.StringParameter{
+				Name:                "b",
+				MarkdownDescription: "IP address of the same IP version.",
+			},
+		},
+		Return: function.NumberReturn{},
+	}
+}
+
+func (f *ipDiffFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var a, b string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &a, &b))
+	if resp.Error != nil {
+		return
+	}
+
+	addrA, err := parseFunctionAddr(0, a)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+	addrB, err := parseFunctionAddr(1, b)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+	if addrA.BitLen() != addrB.BitLen() {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("'%s' and '%s' must be of the same IP version.", a, b))
+		return
+	}
+	diff := new(big.Int).Sub(addrToInt(addrA), addrToInt(addrB))
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, new(big.Float).SetInt(diff)))
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestIpDiffFunction(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    string
+		wantErr bool
+	}{
+		{a: "10.0.1.0", b: "10.0.0.255", want: "1"},
+		{a: "10.0.0.5", b: "10.0.1.10", want: "-261"},
+		{a: "2001:db8:1::", b: "2001:db8::", want: "1208925819614629174706176"},
+		{a: "10.0.0.1", b: "2001:db8::", wantErr: true},
+		{a: "10.0.0.1", b: "invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := runTestFunction(t, NewIpDiffFunction(), types.StringValue(tt.a), types.StringValue(tt.b))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ip_diff(%s, %s) error = %v, wantErr %v", tt.a, tt.b, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		want, _ := new(big.Float).SetString(tt.want)
+		if !got.Equal(types.NumberValue(want)) {
+			t.Errorf("ip_diff(%s, %s) = %v, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*normalizeFunction)(nil)
+
+func NewNormalizeFunction() function.Function {
+	return &normalizeFunction{}
+}
+
+type normalizeFunction struct{}
+
+func (f *normalizeFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize"
+}
+
+func (f *normalizeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Normalize an IP address or prefix",
+		MarkdownDescription: "Returns the canonical representation of an IP address or prefix, e.g. `2001:db8::1` for `2001:DB8:0:0::0001` and `10.0.0.1` for `::ffff:10.0.0.1`. Host bits of prefixes are kept.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "ip",
+				MarkdownDescription: "IP address or prefix.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *normalizeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var ip string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &ip))
+	if resp.Error != nil {
+		return
+	}
+
+	var result string
+	if strings.Contains(ip, "/") {
+		prefix, err := netip.ParsePrefix(ip)
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("'%s' is not a valid prefix.", ip))
+			return
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		result = prefix.String()
+	} else {
+		addr, err := parseFunctionAddr(0, ip)
+		if err != nil {
+			resp.Error = err
+			return
+		}
+		result = addr.String()
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, result))
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestNormalizeFunction(t *testing.T) {
+	tests := []struct {
+		ip      string
+		want    string
+		wantErr bool
+	}{
+		{ip: "2001:DB8:0:0::0001", want: "2001:db8::1"},
+		{ip: "::ffff:10.0.0.1", want: "10.0.0.1"},
+		{ip: "10.0.0.1/24", want: "10.0.0.1/24"},
+		{ip: "::ffff:10.0.0.0/120", want: "10.0.0.0/24"},
+		{ip: "2001:0DB8::/32", want: "2001:db8::/32"},
+		{ip: "10.0.0.1/33", wantErr: true},
+		{ip: "host1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := runTestFunction(t, NewNormalizeFunction(), types.StringValue(tt.ip))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalize(%s) error = %v, wantErr %v", tt.ip, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(types.StringValue(tt.want)) {
+			t.Errorf("normalize(%s) = %v, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*rangeContainsFunction)(nil)
+
+func NewRangeContainsFunction() function.Function {
+	return &rangeContainsFunction{}
+}
+
+type rangeContainsFunction struct{}
+
+func (f *rangeContainsFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "range_contains"
+}
+
+func (f *rangeContainsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Check if an IP range contains an IP address",
+		MarkdownDescription: "Returns `true` if `ip` is between `from_ip` and `to_ip`, including both. Addresses of the other IP version are never contained. The `step` and `align` attributes of pool ranges are not taken into account, use `in_pool` to check if an address can be allocated from a pool.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "from_ip",
+				MarkdownDescription: "First IP address of the range.",
+			},
+			function.StringParameter{
+				Name:                "to_ip",
+				MarkdownDescription: "Last IP address of the range.",
+			},
+			function.StringParameter{
+				Name:                "ip",
+				MarkdownDescription: "IP address.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *rangeContainsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var fromIp, toIp, ip string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &fromIp, &toIp, &ip))
+	if resp.Error != nil {
+		return
+	}
+
+	from, err := parseFunctionRange(0, fromIp, toIp)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+	addr, err := parseFunctionAddr(2, ip)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, from.contains(addr)))
+}
+
+// functionRange is an IP range passed to a provider function.
+type functionRange struct {
+	From netip.Addr
+	To   netip.Addr
+}
+
+func (r functionRange) contains(addr netip.Addr) bool {
+	return addr.BitLen() == r.From.BitLen() && !addr.Less(r.From) && !r.To.Less(addr)
+}
+
+// parseFunctionAddr parses the IP address argument at position. IPv4-mapped
+// IPv6 addresses are returned as IPv4 addresses.
+func parseFunctionAddr(position int64, s string) (netip.Addr, *function.FuncError) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, function.NewArgumentFuncError(position, fmt.Sprintf("'%s' is not a valid IP address.", s))
+	}
+	return addr.Unmap(), nil
+}
+
+// parseFunctionRange parses the range arguments at position and position+1.
+func parseFunctionRange(position int64, fromIp, toIp string) (functionRange, *function.FuncError) {
+	from, err := parseFunctionAddr(position, fromIp)
+	if err != nil {
+		return functionRange{}, err
+	}
+	to, err := parseFunctionAddr(position+1, toIp)
+	if err != nil {
+		return functionRange{}, err
+	}
+	if from.BitLen() != to.BitLen() || to.Less(from) {
+		return functionRange{}, function.NewArgumentFuncError(position+1, fmt.Sprintf("'%s' must not be smaller than '%s' and of the same IP version.", toIp, fromIp))
+	}
+	return functionRange{From: from, To: to}, nil
+}
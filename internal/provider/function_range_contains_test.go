@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFunctions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheckFunctions(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFunctionsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckOutput("range_contains", "true"),
+					resource.TestCheckOutput("ip_add", "10.0.1.4"),
+					resource.TestCheckOutput("ip_diff", "-261"),
+					resource.TestCheckOutput("range_size", "17"),
+					resource.TestCheckOutput("normalize", "2001:db8::1"),
+					resource.TestCheckOutput("in_pool", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccFunctionsConfig() string {
+	return `
+	locals {
+		pool = {
+			name   = "POOL1"
+			ranges = [{ from_ip = "10.0.0.10", to_ip = "10.0.0.20" }]
+		}
+	}
+
+	output "range_contains" {
+		value = provider::ipam::range_contains("10.0.0.10", "10.0.0.20", "10.0.0.15")
+	}
+
+	output "ip_add" {
+		value = provider::ipam::ip_add("10.0.0.250", 10)
+	}
+
+	output "ip_diff" {
+		value = provider::ipam::ip_diff("10.0.0.5", "10.0.1.10")
+	}
+
+	output "range_size" {
+		value = provider::ipam::range_size("10.0.0.4", "10.0.0.20")
+	}
+
+	output "normalize" {
+		value = provider::ipam::normalize("2001:DB8:0:0::0001")
+	}
+
+	output "in_pool" {
+		value = provider::ipam::in_pool(local.pool, "10.0.0.20")
+	}
+	`
+}
+
+func TestRangeContainsFunction(t *testing.T) {
+	tests := []struct {
+		from, to, ip string
+		want         bool
+		wantErr      bool
+	}{
+		{from: "10.0.0.10", to: "10.0.0.20", ip: "10.0.0.10", want: true},
+		{from: "10.0.0.10", to: "10.0.0.20", ip: "10.0.0.20", want: true},
+		{from: "10.0.0.10", to: "10.0.0.20", ip: "10.0.0.21", want: false},
+		{from: "10.0.0.10", to: "10.0.0.10", ip: "::ffff:10.0.0.10", want: true},
+		{from: "2001:db8::1", to: "2001:db8::ff", ip: "10.0.0.1", want: false},
+		{from: "10.0.0.20", to: "10.0.0.10", ip: "10.0.0.15", wantErr: true},
+		{from: "10.0.0.10", to: "2001:db8::1", ip: "10.0.0.15", wantErr: true},
+		{from: "10.0.0.10", to: "10.0.0.20", ip: "10.0.0.256", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := runTestFunction(t, NewRangeContainsFunction(), types.StringValue(tt.from), types.StringValue(tt.to), types.StringValue(tt.ip))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("range_contains(%s, %s, %s) error = %v, wantErr %v", tt.from, tt.to, tt.ip, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(types.BoolValue(tt.want)) {
+			t.Errorf("range_contains(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = (*rangeSizeFunction)(nil)
+
+func NewRangeSizeFunction() function.Function {
+	return &rangeSizeFunction{}
+}
+
+type rangeSizeFunction struct{}
+
+func (f *rangeSizeFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "range_size"
+}
+
+func (f *rangeSizeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Return the number of addresses of an IP range",
+		MarkdownDescription: "Returns the number of addresses from `from_ip` to `to_ip`, including both.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "from_ip",
+				MarkdownDescription: "First IP address of the range.",
+			},
+			function.StringParameter{
+				Name:                "to_ip",
+				MarkdownDescription: "Last IP address of the range.",
+			},
+		},
+		Return: function.NumberReturn{},
+	}
+}
+
+func (f *rangeSizeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var fromIp, toIp string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &fromIp, &toIp))
+	if resp.Error != nil {
+		return
+	}
+
+	r, err := parseFunctionRange(0, fromIp, toIp)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+	size := new(big.Int).Sub(addrToInt(r.To), addrToInt(r.From))
+	size.Add(size, big.NewInt(1))
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, new(big.Float).SetInt(size)))
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRangeSizeFunction(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+		wantErr  bool
+	}{
+		{from: "10.0.0.4", to: "10.0.0.20", want: "17"},
+		{from: "10.0.0.4", to: "10.0.0.4", want: "1"},
+		{from: "::", to: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", want: "340282366920938463463374607431768211456"},
+		{from: "10.0.0.20", to: "10.0.0.4", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := runTestFunction(t, NewRangeSizeFunction(), types.StringValue(tt.from), types.StringValue(tt.to))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("range_size(%s, %s) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		want, _ := new(big.Float).SetString(tt.want)
+		if !got.Equal(types.NumberValue(want)) {
+			t.Errorf("range_size(%s, %s) = %v, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
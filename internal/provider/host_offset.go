@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// hostOffset derives the position of the first address of a host in its pool
+// from a number in the host ID.
+type hostOffset struct {
+	pattern *regexp.Regexp
+	offset  int64
+	stride  int64
+	strict  bool
+}
+
+func newHostOffset(config *providerDataHostOffset) (*hostOffset, error) {
+	o := &hostOffset{offset: config.Offset.ValueInt64(), stride: 1}
+	pattern, err := regexp.Compile(config.Pattern.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("'pattern' is not a valid regular expression: %s", err.Error())
+	}
+	if pattern.NumSubexp() < 1 {
+		return nil, fmt.Errorf("'pattern' must contain a capture group")
+	}
+	o.pattern = pattern
+	if !config.Stride.IsNull() {
+		o.stride = config.Stride.ValueInt64()
+		if o.stride < 1 {
+			return nil, fmt.Errorf("'stride' must be at least 1")
+		}
+	}
+	if !config.OnConflict.IsNull() {
+		switch config.OnConflict.ValueString() {
+		case "allocate":
+		case "error":
+			o.strict = true
+		default:
+			return nil, fmt.Errorf("'on_conflict' must be either 'allocate' or 'error'")
+		}
+	}
+	return o, nil
+}
+
+// Position returns the position of the first address of host in the pool,
+// or false if the host ID does not match the pattern, the first capture
+// group is not a number or the position does not fit into an int64.
+func (o *hostOffset) Position(host string) (int64, bool) {
+	match := o.pattern.FindStringSubmatch(host)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if n > (math.MaxInt64-max(o.offset, 0))/o.stride || n < (math.MinInt64-min(o.offset, 0))/o.stride {
+		return 0, false
+	}
+	return n*o.stride + o.offset, true
+}
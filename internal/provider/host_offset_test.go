@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestHostOffsetPosition(t *testing.T) {
+	o, err := newHostOffset(&providerDataHostOffset{Pattern: types.StringValue(`^rack(\d+)-srv(\d+)$`), Offset: types.Int64Value(-1), Stride: types.Int64Value(4)})
+	if err != nil {
+		t.Fatalf("newHostOffset() error = %v", err)
+	}
+	tests := []struct {
+		host     string
+		position int64
+		ok       bool
+	}{
+		{"rack12-srv07", 47, true},
+		{"rack0-srv01", -1, true},
+		{"leaf-101", 0, false},
+		{"rack99999999999999999999-srv01", 0, false},
+		{"rack2305843009213693951-srv01", 9223372036854775803, true},
+		{"rack2305843009213693952-srv01", 0, false},
+	}
+	for _, tt := range tests {
+		position, ok := o.Position(tt.host)
+		if position != tt.position || ok != tt.ok {
+			t.Errorf("Position(%s) = %d, %v, want %d, %v", tt.host, position, ok, tt.position, tt.ok)
+		}
+	}
+}
+
+func TestNewHostOffsetInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config providerDataHostOffset
+	}{
+		{"invalid pattern", providerDataHostOffset{Pattern: types.StringValue(`leaf-(\d+`)}},
+		{"pattern without capture group", providerDataHostOffset{Pattern: types.StringValue(`leaf-\d+`)}},
+		{"invalid stride", providerDataHostOffset{Pattern: types.StringValue(`leaf-(\d+)`), Stride: types.Int64Value(0)}},
+		{"invalid on_conflict", providerDataHostOffset{Pattern: types.StringValue(`leaf-(\d+)`), OnConflict: types.StringValue("ignore")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newHostOffset(&tt.config); err == nil {
+				t.Errorf("newHostOffset() error = nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+// poolsFile is the structure of a pools file. JSON is parsed as a subset of
+// YAML.
+type poolsFile struct {
+	Pools       []poolsFilePool       `yaml:"pools"`
+	PrefixPools []poolsFilePrefixPool `yaml:"prefix_pools"`
+}
+
+type poolsFilePool struct {
+	Name            string                 `yaml:"name"`
+	PrefixLength    *int64                 `yaml:"prefix_length"`
+	Gateway         *string                `yaml:"gateway"`
+	Ranges          []poolsFilePoolRange   `yaml:"ranges"`
+	Addresses       []poolsFilePoolAddress `yaml:"addresses"`
+	DnsZone         *string                `yaml:"dns_zone"`
+	DnsNameTemplate *string                `yaml:"dns_name_template"`
+	DnsReverseZone  *string                `yaml:"dns_reverse_zone"`
+	DnsTtl          *int64                 `yaml:"dns_ttl"`
+	WarnThreshold   *int64                 `yaml:"warn_threshold"`
+	ErrorThreshold  *int64                 `yaml:"error_threshold"`
+	OverflowPools   []string               `yaml:"overflow_pools"`
+	HostOffset      *poolsFileHostOffset   `yaml:"host_offset"`
+}
+
+type poolsFileHostOffset struct {
+	Pattern    string  `yaml:"pattern"`
+	Offset     *int64  `yaml:"offset"`
+	Stride     *int64  `yaml:"stride"`
+	OnConflict *string `yaml:"on_conflict"`
+}
+
+type poolsFilePoolRange struct {
+	FromIP       string            `yaml:"from_ip"`
+	ToIP         string            `yaml:"to_ip"`
+	PrefixLength *int64            `yaml:"prefix_length"`
+	Gateway      *string           `yaml:"gateway"`
+	Labels       map[string]string `yaml:"labels"`
+	Step         *int64            `yaml:"step"`
+	Align        *int64            `yaml:"align"`
+	Priority     *int64            `yaml:"priority"`
+	Weight       *int64            `yaml:"weight"`
+}
+
+type poolsFilePoolAddress struct {
+	IP           string            `yaml:"ip"`
+	PrefixLength *int64            `yaml:"prefix_length"`
+	Gateway      *string           `yaml:"gateway"`
+	Labels       map[string]string `yaml:"labels"`
+	Priority     *int64            `yaml:"priority"`
+	Weight       *int64            `yaml:"weight"`
+}
+
+type poolsFilePrefixPool struct {
+	Name     string   `yaml:"name"`
+	Prefixes []string `yaml:"prefixes"`
+}
+
+// loadPoolsFile reads pools and prefix pools from a YAML or JSON file.
+func loadPoolsFile(name string) ([]providerDataPool, []providerDataPrefixPool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var file poolsFile
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+
+	pools := make([]providerDataPool, 0, len(file.Pools))
+	for _, p := range file.Pools {
+		if p.Name == "" {
+			return nil, nil, fmt.Errorf("pool without 'name'")
+		}
+		pool := providerDataPool{
+			Name:            types.StringValue(p.Name),
+			PrefixLength:    types.Int64PointerValue(p.PrefixLength),
+			Gateway:         types.StringPointerValue(p.Gateway),
+			DnsZone:         types.StringPointerValue(p.DnsZone),
+			DnsNameTemplate: types.StringPointerValue(p.DnsNameTemplate),
+			DnsReverseZone:  types.StringPointerValue(p.DnsReverseZone),
+			DnsTtl:          types.Int64PointerValue(p.DnsTtl),
+			WarnThreshold:   types.Int64PointerValue(p.WarnThreshold),
+			ErrorThreshold:  types.Int64PointerValue(p.ErrorThreshold),
+		}
+		if p.HostOffset != nil {
+			pool.HostOffset = &providerDataHostOffset{
+				Pattern:    types.StringValue(p.HostOffset.Pattern),
+				Offset:     types.Int64PointerValue(p.HostOffset.Offset),
+				Stride:     types.Int64PointerValue(p.HostOffset.Stride),
+				OnConflict: types.StringPointerValue(p.HostOffset.OnConflict),
+			}
+		}
+		for _, overflow := range p.OverflowPools {
+			pool.OverflowPools = append(pool.OverflowPools, types.StringValue(overflow))
+		}
+		for _, r := range p.Ranges {
+			pool.Ranges = append(pool.Ranges, providerDataPoolRange{
+				FromIP:       types.StringValue(r.FromIP),
+				ToIP:         types.StringValue(r.ToIP),
+				PrefixLength: types.Int64PointerValue(r.PrefixLength),
+				Gateway:      types.StringPointerValue(r.Gateway),
+				Labels:       stringValueMap(r.Labels),
+				Step:         types.Int64PointerValue(r.Step),
+				Align:        types.Int64PointerValue(r.Align),
+				Priority:     types.Int64PointerValue(r.Priority),
+				Weight:       types.Int64PointerValue(r.Weight),
+			})
+		}
+		for _, a := range p.Addresses {
+			pool.Addresses = append(pool.Addresses, providerDataPoolAddress{
+				IP:           types.StringValue(a.IP),
+				PrefixLength: types.Int64PointerValue(a.PrefixLength),
+				Gateway:      types.StringPointerValue(a.Gateway),
+				Labels:       stringValueMap(a.Labels),
+				Priority:     types.Int64PointerValue(a.Priority),
+				Weight:       types.Int64PointerValue(a.Weight),
+			})
+		}
+		pools = append(pools, pool)
+	}
+
+	prefixPools := make([]providerDataPrefixPool, 0, len(file.PrefixPools))
+	for _, p := range file.PrefixPools {
+		if p.Name == "" {
+			return nil, nil, fmt.Errorf("prefix pool without 'name'")
+		}
+		pool := providerDataPrefixPool{
+			Name: types.StringValue(p.Name),
+		}
+		for _, prefix := range p.Prefixes {
+			pool.Prefixes = append(pool.Prefixes, types.StringValue(prefix))
+		}
+		prefixPools = append(prefixPools, pool)
+	}
+
+	return pools, prefixPools, nil
+}
+
+// stringValueMap converts a map of strings, keeping nil maps as null.
+func stringValueMap(m map[string]string) map[string]types.String {
+	if m == nil {
+		return nil
+	}
+	values := make(map[string]types.String, len(m))
+	for k, v := range m {
+		values[k] = types.StringValue(v)
+	}
+	return values
+}
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestLoadPoolsFile(t *testing.T) {
+	pools, prefixPools, err := loadPoolsFile("testdata/pools.yaml")
+	if err != nil {
+		t.Fatalf("loadPoolsFile() error = %v", err)
+	}
+	wantPools := []providerDataPool{
+		{
+			Name:         types.StringValue("FILE_POOL1"),
+			PrefixLength: types.Int64Value(24),
+			Gateway:      types.StringValue("10.2.0.254"),
+			Ranges: []providerDataPoolRange{
+				{FromIP: types.StringValue("10.2.0.1"), ToIP: types.StringValue("10.2.0.10"), PrefixLength: types.Int64Null(), Gateway: types.StringNull()},
+				{FromIP: types.StringValue("10.2.1.1"), ToIP: types.StringValue("10.2.1.10"), PrefixLength: types.Int64Value(25), Gateway: types.StringValue("10.2.1.126")},
+			},
+			Addresses: []providerDataPoolAddress{
+				{IP: types.StringValue("10.2.2.1"), PrefixLength: types.Int64Value(23), Gateway: types.StringValue("10.2.3.254")},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pools, wantPools) {
+		t.Errorf("loadPoolsFile() pools = %+v, want %+v", pools, wantPools)
+	}
+	wantPrefixPools := []providerDataPrefixPool{
+		{Name: types.StringValue("FILE_PREFIX_POOL1"), Prefixes: []types.String{types.StringValue("10.3.0.0/16")}},
+	}
+	if !reflect.DeepEqual(prefixPools, wantPrefixPools) {
+		t.Errorf("loadPoolsFile() prefix pools = %+v, want %+v", prefixPools, wantPrefixPools)
+	}
+
+	pools, prefixPools, err = loadPoolsFile("testdata/pools.json")
+	if err != nil {
+		t.Fatalf("loadPoolsFile() error = %v", err)
+	}
+	if len(pools) != 1 || pools[0].Name.ValueString() != "FILE_POOL2" || pools[0].PrefixLength.ValueInt64() != 64 || len(pools[0].Ranges) != 1 || len(prefixPools) != 0 {
+		t.Errorf("loadPoolsFile() pools = %+v, prefix pools = %+v", pools, prefixPools)
+	}
+}
+
+func TestLoadPoolsFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown attribute": "pools:\n  - name: POOL\n    prefixlength: 24\n",
+		"missing name":      "pools:\n  - prefix_length: 24\n",
+		"invalid type":      "pools:\n  - name: POOL\n    prefix_length: large\n",
+		"invalid syntax":    "pools: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, "pools.yaml")
+			if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := loadPoolsFile(file); err == nil {
+				t.Errorf("loadPoolsFile() accepted %q", content)
+			}
+		})
+	}
+	if _, _, err := loadPoolsFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("loadPoolsFile() accepted a missing file")
+	}
+}
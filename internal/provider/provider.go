@@ -3,14 +3,19 @@ package provider
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
+var _ provider.ProviderWithFunctions = (*ipamProvider)(nil)
+
 func New() provider.Provider {
 	return &ipamProvider{}
 }
@@ -25,28 +30,83 @@ type providerAddress struct {
 
 // providerData can be used to store data from the Terraform configuration.
 type providerData struct {
-	Pools []providerDataPool `tfsdk:"pools"`
+	Pools          []providerDataPool          `tfsdk:"pools"`
+	DnsUpdate      *providerDataDnsUpdate      `tfsdk:"dns_update"`
+	PoolsFile      types.String                `tfsdk:"pools_file"`
+	DataModelPools []providerDataDataModelPool `tfsdk:"data_model_pools"`
+	PoolsCsv       types.String                `tfsdk:"pools_csv"`
+	AssignmentsCsv types.String                `tfsdk:"assignments_csv"`
+	PrefixPools    []providerDataPrefixPool    `tfsdk:"prefix_pools"`
+	Assignments    []providerAssignment        `tfsdk:"-"`
+	DnsUpdater     *dnsUpdater                 `tfsdk:"-"`
 }
 
 type providerDataPool struct {
-	Name         types.String              `tfsdk:"name"`
-	PrefixLength types.Int64               `tfsdk:"prefix_length"`
-	Gateway      types.String              `tfsdk:"gateway"`
-	Ranges       []providerDataPoolRange   `tfsdk:"ranges"`
-	Addresses    []providerDataPoolAddress `tfsdk:"addresses"`
+	Name            types.String              `tfsdk:"name"`
+	PrefixLength    types.Int64               `tfsdk:"prefix_length"`
+	Gateway         types.String              `tfsdk:"gateway"`
+	Ranges          []providerDataPoolRange   `tfsdk:"ranges"`
+	Addresses       []providerDataPoolAddress `tfsdk:"addresses"`
+	DnsZone         types.String              `tfsdk:"dns_zone"`
+	DnsNameTemplate types.String              `tfsdk:"dns_name_template"`
+	DnsReverseZone  types.String              `tfsdk:"dns_reverse_zone"`
+	DnsTtl          types.Int64               `tfsdk:"dns_ttl"`
+	WarnThreshold   types.Int64               `tfsdk:"warn_threshold"`
+	ErrorThreshold  types.Int64               `tfsdk:"error_threshold"`
+	OverflowPools   []types.String            `tfsdk:"overflow_pools"`
+	HostOffset      *providerDataHostOffset   `tfsdk:"host_offset"`
 }
 
 type providerDataPoolRange struct {
-	FromIP       types.String `tfsdk:"from_ip"`
-	ToIP         types.String `tfsdk:"to_ip"`
-	PrefixLength types.Int64  `tfsdk:"prefix_length"`
-	Gateway      types.String `tfsdk:"gateway"`
+	FromIP       types.String            `tfsdk:"from_ip"`
+	ToIP         types.String            `tfsdk:"to_ip"`
+	PrefixLength types.Int64             `tfsdk:"prefix_length"`
+	Gateway      types.String            `tfsdk:"gateway"`
+	Labels       map[string]types.String `tfsdk:"labels"`
+	Step         types.Int64             `tfsdk:"step"`
+	Align        types.Int64             `tfsdk:"align"`
+	Priority     types.Int64             `tfsdk:"priority"`
+	Weight       types.Int64             `tfsdk:"weight"`
 }
 
 type providerDataPoolAddress struct {
-	IP           types.String `tfsdk:"ip"`
-	PrefixLength types.Int64  `tfsdk:"prefix_length"`
-	Gateway      types.String `tfsdk:"gateway"`
+	IP           types.String            `tfsdk:"ip"`
+	PrefixLength types.Int64             `tfsdk:"prefix_length"`
+	Gateway      types.String            `tfsdk:"gateway"`
+	Labels       map[string]types.String `tfsdk:"labels"`
+	Priority     types.Int64             `tfsdk:"priority"`
+	Weight       types.Int64             `tfsdk:"weight"`
+}
+
+type providerDataDataModelPool struct {
+	File            types.String `tfsdk:"file"`
+	Path            types.String `tfsdk:"path"`
+	NameField       types.String `tfsdk:"name_field"`
+	NameTemplate    types.String `tfsdk:"name_template"`
+	CidrField       types.String `tfsdk:"cidr_field"`
+	GatewayField    types.String `tfsdk:"gateway_field"`
+	ExclusionsField types.String `tfsdk:"exclusions_field"`
+}
+
+type providerDataDnsUpdate struct {
+	Server        types.String `tfsdk:"server"`
+	Transport     types.String `tfsdk:"transport"`
+	TsigKeyName   types.String `tfsdk:"tsig_key_name"`
+	TsigAlgorithm types.String `tfsdk:"tsig_algorithm"`
+	TsigSecret    types.String `tfsdk:"tsig_secret"`
+	OnFailure     types.String `tfsdk:"on_failure"`
+}
+
+type providerDataHostOffset struct {
+	Pattern    types.String `tfsdk:"pattern"`
+	Offset     types.Int64  `tfsdk:"offset"`
+	Stride     types.Int64  `tfsdk:"stride"`
+	OnConflict types.String `tfsdk:"on_conflict"`
+}
+
+type providerDataPrefixPool struct {
+	Name     types.String   `tfsdk:"name"`
+	Prefixes []types.String `tfsdk:"prefixes"`
 }
 
 // Metadata returns the provider type name.
@@ -59,7 +119,7 @@ func (p *ipamProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 		Attributes: map[string]schema.Attribute{
 			"pools": schema.ListNestedAttribute{
 				MarkdownDescription: "A list of managed IP pools.",
-				Required:            true,
+				Optional:            true,
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
 						"name": schema.StringAttribute{
@@ -95,6 +155,27 @@ func (p *ipamProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 										MarkdownDescription: "Gateway IP.",
 										Optional:            true,
 									},
+									"labels": schema.MapAttribute{
+										MarkdownDescription: "Labels of the range, e.g. `{ rack = \"r12\" }`, to allocate addresses to hosts of an `ipam_allocate` resource with a matching `selector`.",
+										ElementType:         types.StringType,
+										Optional:            true,
+									},
+									"step": schema.Int64Attribute{
+										MarkdownDescription: "Only use every `step`-th address of the range, e.g. `4` to leave room for a /30 block per host. Defaults to `1`.",
+										Optional:            true,
+									},
+									"align": schema.Int64Attribute{
+										MarkdownDescription: "Start the range at the first address which is a multiple of `align`, which must be a power of two, e.g. `4` to align the addresses with /30 blocks. Defaults to `1`.",
+										Optional:            true,
+									},
+									"priority": schema.Int64Attribute{
+										MarkdownDescription: "Addresses of ranges and addresses with a higher priority are allocated first, e.g. `-1` to only allocate the addresses of this range once all others are in use. Defaults to `0`.",
+										Optional:            true,
+									},
+									"weight": schema.Int64Attribute{
+										MarkdownDescription: "Relative probability of selecting an address of this range with the `random` strategy of `ipam_allocate`. Defaults to `1`.",
+										Optional:            true,
+									},
 								},
 							},
 						},
@@ -115,9 +196,169 @@ func (p *ipamProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 										MarkdownDescription: "Gateway IP.",
 										Optional:            true,
 									},
+									"labels": schema.MapAttribute{
+										MarkdownDescription: "Labels of the address, e.g. `{ rack = \"r12\" }`, to allocate addresses to hosts of an `ipam_allocate` resource with a matching `selector`.",
+										ElementType:         types.StringType,
+										Optional:            true,
+									},
+									"priority": schema.Int64Attribute{
+										MarkdownDescription: "Addresses of ranges and addresses with a higher priority are allocated first, e.g. `-1` to only allocate this address once all others are in use. Defaults to `0`.",
+										Optional:            true,
+									},
+									"weight": schema.Int64Attribute{
+										MarkdownDescription: "Relative probability of selecting this address with the `random` strategy of `ipam_allocate`. Defaults to `1`.",
+										Optional:            true,
+									},
+								},
+							},
+						},
+						"dns_zone": schema.StringAttribute{
+							MarkdownDescription: "Forward DNS zone, e.g. `example.com`. If set and `dns_update` is configured, `ipam_allocate` keeps A/AAAA and PTR records of its hosts up to date. Hosts allocated from an overflow pool are registered with the DNS attributes of that pool.",
+							Optional:            true,
+						},
+						"dns_name_template": schema.StringAttribute{
+							MarkdownDescription: "Record name within `dns_zone`, where `{host}` is replaced by the host ID, e.g. `{host}-mgmt`. Defaults to `{host}`.",
+							Optional:            true,
+						},
+						"dns_reverse_zone": schema.StringAttribute{
+							MarkdownDescription: "Reverse DNS zone for PTR records, e.g. `10.in-addr.arpa`. Defaults to the zone at the octet (IPv4) or nibble (IPv6) boundary of the prefix length of each address.",
+							Optional:            true,
+						},
+						"dns_ttl": schema.Int64Attribute{
+							MarkdownDescription: "TTL of DNS records in seconds, at least `1`. Defaults to `3600`.",
+							Optional:            true,
+						},
+						"warn_threshold": schema.Int64Attribute{
+							MarkdownDescription: "Utilization in percent above which planning an `ipam_allocate` resource of this pool shows a warning with the number of remaining addresses. Only plans which change the utilization of the pool are checked. Resources whose pool overflows into this pool check its thresholds with the addresses allocated from it. Utilization counts the addresses of the planned resource and known assignments from `assignments_csv`, but not addresses of other `ipam_allocate` resources.",
+							Optional:            true,
+						},
+						"error_threshold": schema.Int64Attribute{
+							MarkdownDescription: "Utilization in percent above which planning an `ipam_allocate` resource of this pool fails if the plan allocates additional addresses. Plans which reduce utilization only show a warning, so that addresses can still be released.",
+							Optional:            true,
+						},
+						"host_offset": schema.SingleNestedAttribute{
+							MarkdownDescription: "Derive the first IP address of new hosts of an `ipam_allocate` resource from a number in their host ID, e.g. `leaf-101`. The address is the address of the pool at position `number * stride + offset`, counting from 0 in declaration order of ranges and addresses, regardless of their `priority`. Hosts whose ID does not match the pattern are allocated as usual.",
+							Optional:            true,
+							Attributes: map[string]schema.Attribute{
+								"pattern": schema.StringAttribute{
+									MarkdownDescription: "Regular expression matching the host ID, whose first capture group is the number, e.g. `^leaf-(\\d+)$`.",
+									Required:            true,
+								},
+								"offset": schema.Int64Attribute{
+									MarkdownDescription: "Offset added to the number. Defaults to `0`.",
+									Optional:            true,
+								},
+								"stride": schema.Int64Attribute{
+									MarkdownDescription: "Factor the number is multiplied with. Defaults to `1`.",
+									Optional:            true,
+								},
+								"on_conflict": schema.StringAttribute{
+									MarkdownDescription: "Conflict policy if the derived address is outside of the pool or already in use, either `allocate` to allocate another address or `error` to fail the operation. Defaults to `allocate`.",
+									Optional:            true,
 								},
 							},
 						},
+						"overflow_pools": schema.ListAttribute{
+							MarkdownDescription: "Names of other pools to allocate from, in order, once all addresses of this pool are in use. Contiguous addresses of a host are always allocated from a single pool. As addresses allocated by overflow are only known to the resources of this pool, an overflow pool can only be the overflow pool of a single pool and can not be the `pool` of an `ipam_allocate` resource.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"dns_update": schema.SingleNestedAttribute{
+				MarkdownDescription: "Send TSIG signed RFC 2136 dynamic DNS updates for hosts of `ipam_allocate` resources whose pool has a `dns_zone`.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"server": schema.StringAttribute{
+						MarkdownDescription: "DNS server address with optional port, e.g. `192.0.2.53` or `192.0.2.53:5353`.",
+						Required:            true,
+					},
+					"transport": schema.StringAttribute{
+						MarkdownDescription: "Transport protocol, either `udp` or `tcp`. Defaults to `udp`.",
+						Optional:            true,
+					},
+					"tsig_key_name": schema.StringAttribute{
+						MarkdownDescription: "TSIG key name. Updates are not signed if not set.",
+						Optional:            true,
+					},
+					"tsig_algorithm": schema.StringAttribute{
+						MarkdownDescription: "TSIG algorithm, one of `hmac-md5`, `hmac-sha1`, `hmac-sha224`, `hmac-sha256`, `hmac-sha384` or `hmac-sha512`. Defaults to `hmac-sha256`.",
+						Optional:            true,
+					},
+					"tsig_secret": schema.StringAttribute{
+						MarkdownDescription: "Base64 encoded TSIG secret.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"on_failure": schema.StringAttribute{
+						MarkdownDescription: "Failure policy, either `warn` to report failed updates as warnings or `error` to fail the operation. Allocated addresses are saved in the state in both cases. Defaults to `warn`.",
+						Optional:            true,
+					},
+				},
+			},
+			"pools_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a YAML or JSON file with additional `pools` and `prefix_pools` using the same attribute names as the provider configuration. Pools from the file are merged with inline pools. This can also be set as the `IPAM_POOLS_FILE` environment variable.",
+				Optional:            true,
+			},
+			"data_model_pools": schema.ListNestedAttribute{
+				MarkdownDescription: "A list of YAML data models, e.g. Network-as-Code data models, to derive pools from. One pool is derived per object at `path` and covers all usable addresses of its subnet except the gateway and exclusions. Subnets with more than 65536 addresses, e.g. IPv6 `/64` subnets, and subnets without a gateway are rejected. Objects without a subnet are skipped. Fields are dot-separated key paths relative to the object, which use the first element of lists.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"file": schema.StringAttribute{
+							MarkdownDescription: "Path to the YAML file.",
+							Required:            true,
+						},
+						"path": schema.StringAttribute{
+							MarkdownDescription: "Dot-separated key path to the objects, e.g. `apic.tenants.bridge_domains`. Lists along the path are traversed.",
+							Required:            true,
+						},
+						"name_field": schema.StringAttribute{
+							MarkdownDescription: "Field with the pool name. Defaults to `name`.",
+							Optional:            true,
+						},
+						"name_template": schema.StringAttribute{
+							MarkdownDescription: "Template of the pool name, in which `{field}` is replaced with the value of a field. Fields starting with a key of `path` refer to the object at that key, e.g. `{tenants.name}_{name}` for bridge domains with the same name in different tenants. Overrides `name_field`.",
+							Optional:            true,
+						},
+						"cidr_field": schema.StringAttribute{
+							MarkdownDescription: "Field with the subnet in CIDR notation, e.g. `subnets.ip`. If the address is not the network address, e.g. `10.0.0.1/24`, it is used as gateway.",
+							Required:            true,
+						},
+						"gateway_field": schema.StringAttribute{
+							MarkdownDescription: "Field with the gateway IP.",
+							Optional:            true,
+						},
+						"exclusions_field": schema.StringAttribute{
+							MarkdownDescription: "Field with a list of excluded IP addresses, prefixes or ranges in the form `from-to`.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"pools_csv": schema.StringAttribute{
+				MarkdownDescription: "Path to a CSV file with additional pools, e.g. exported from a spreadsheet. The header row must contain a `pool` column with the pool name and every row adds either a range (`from` and `to`), a single address (`ip`) or all usable addresses of a subnet with at most 65536 addresses except its gateway (`cidr`). The prefix length is taken from the `prefix_length` or `cidr` column and the gateway from the `gateway` column or the address part of the `cidr` column, e.g. `10.0.0.1/24`. Other columns are ignored.",
+				Optional:            true,
+			},
+			"assignments_csv": schema.StringAttribute{
+				MarkdownDescription: "Path to a CSV file with known assignments, e.g. exported from a spreadsheet, with the columns `host`, `ip` and optionally `pool`. Hosts of an `ipam_allocate` resource without allocated IPs adopt the IPs assigned to them if they belong to the pool or its overflow pools and match the `selector` of the host, all other assigned IPs are reserved and not allocated to other hosts. Assignments without a pool apply to all pools.",
+				Optional:            true,
+			},
+			"prefix_pools": schema.ListNestedAttribute{
+				MarkdownDescription: "A list of managed prefix pools.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Prefix pool name.",
+							Required:            true,
+						},
+						"prefixes": schema.ListAttribute{
+							MarkdownDescription: "A list of parent prefixes in CIDR notation, e.g. `10.0.0.0/16`.",
+							ElementType:         types.StringType,
+							Required:            true,
+						},
 					},
 				},
 			},
@@ -134,6 +375,83 @@ func (p *ipamProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
+	poolsFile := os.Getenv("IPAM_POOLS_FILE")
+	if !config.PoolsFile.IsNull() {
+		poolsFile = config.PoolsFile.ValueString()
+	}
+	if poolsFile != "" {
+		pools, prefixPools, err := loadPoolsFile(poolsFile)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid 'pools_file' configured.",
+				fmt.Sprintf("Failed to load pools file '%s': %s", poolsFile, err.Error()),
+			)
+			return
+		}
+		config.Pools = append(config.Pools, pools...)
+		config.PrefixPools = append(config.PrefixPools, prefixPools...)
+	}
+
+	for _, source := range config.DataModelPools {
+		pools, err := loadDataModelPools(source)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid 'data_model_pools' configured.",
+				fmt.Sprintf("Failed to load pools from data model '%s': %s", source.File.ValueString(), err.Error()),
+			)
+			return
+		}
+		config.Pools = append(config.Pools, pools...)
+	}
+
+	if !config.PoolsCsv.IsNull() {
+		pools, err := loadPoolsCsv(config.PoolsCsv.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid 'pools_csv' configured.",
+				fmt.Sprintf("Failed to load pools from '%s': %s", config.PoolsCsv.ValueString(), err.Error()),
+			)
+			return
+		}
+		config.Pools = append(config.Pools, pools...)
+	}
+
+	if !config.AssignmentsCsv.IsNull() {
+		assignments, err := loadAssignmentsCsv(config.AssignmentsCsv.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid 'assignments_csv' configured.",
+				fmt.Sprintf("Failed to load assignments from '%s': %s", config.AssignmentsCsv.ValueString(), err.Error()),
+			)
+			return
+		}
+		config.Assignments = assignments
+	}
+
+	poolNames := make(map[string]bool)
+	for p := range config.Pools {
+		if poolNames[config.Pools[p].Name.ValueString()] {
+			resp.Diagnostics.AddError(
+				"Duplicate pool configured.",
+				fmt.Sprintf("Pool '%s' is configured more than once.", config.Pools[p].Name.ValueString()),
+			)
+			return
+		}
+		poolNames[config.Pools[p].Name.ValueString()] = true
+	}
+	prefixPoolNames := make(map[string]bool)
+	for p := range config.PrefixPools {
+		if prefixPoolNames[config.PrefixPools[p].Name.ValueString()] {
+			resp.Diagnostics.AddError(
+				"Duplicate prefix pool configured.",
+				fmt.Sprintf("Prefix pool '%s' is configured more than once.", config.PrefixPools[p].Name.ValueString()),
+			)
+			return
+		}
+		prefixPoolNames[config.PrefixPools[p].Name.ValueString()] = true
+	}
+
+	overflowOwners := make(map[string]string)
 	for p := range config.Pools {
 		globalPrefixLength := false
 		if !config.Pools[p].PrefixLength.IsNull() {
@@ -204,10 +522,31 @@ func (p *ipamProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 				)
 				return
 			}
+			if step := config.Pools[p].Ranges[r].Step; !step.IsNull() && step.ValueInt64() < 1 {
+				resp.Diagnostics.AddError(
+					"Invalid 'step' configured.",
+					fmt.Sprintf("Range '%s-%s', 'step' must be at least 1.", config.Pools[p].Ranges[r].FromIP.ValueString(), config.Pools[p].Ranges[r].ToIP.ValueString()),
+				)
+				return
+			}
+			if align := config.Pools[p].Ranges[r].Align.ValueInt64(); !config.Pools[p].Ranges[r].Align.IsNull() && (align < 1 || align > 1<<32 || align&(align-1) != 0) {
+				resp.Diagnostics.AddError(
+					"Invalid 'align' configured.",
+					fmt.Sprintf("Range '%s-%s', 'align' must be a power of two up to 4294967296.", config.Pools[p].Ranges[r].FromIP.ValueString(), config.Pools[p].Ranges[r].ToIP.ValueString()),
+				)
+				return
+			}
+			if weight := config.Pools[p].Ranges[r].Weight; !weight.IsNull() && weight.ValueInt64() < 1 {
+				resp.Diagnostics.AddError(
+					"Invalid 'weight' configured.",
+					fmt.Sprintf("Range '%s-%s', 'weight' must be at least 1.", config.Pools[p].Ranges[r].FromIP.ValueString(), config.Pools[p].Ranges[r].ToIP.ValueString()),
+				)
+				return
+			}
 			if err := ValidateIPRange(config.Pools[p].Ranges[r].FromIP.ValueString(), config.Pools[p].Ranges[r].ToIP.ValueString()); err {
 				resp.Diagnostics.AddError(
 					"Invalid range configured.",
-					fmt.Sprintf("Range '%s-%s', 'from_ip' must be smaller than 'to_ip'.", config.Pools[p].Ranges[r].FromIP.ValueString(), config.Pools[p].Ranges[r].ToIP.ValueString()),
+					fmt.Sprintf("Range '%s-%s', 'from_ip' must be smaller than 'to_ip' and of the same IP version.", config.Pools[p].Ranges[r].FromIP.ValueString(), config.Pools[p].Ranges[r].ToIP.ValueString()),
 				)
 				return
 			}
@@ -245,6 +584,13 @@ func (p *ipamProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 				)
 				return
 			}
+			if weight := config.Pools[p].Addresses[a].Weight; !weight.IsNull() && weight.ValueInt64() < 1 {
+				resp.Diagnostics.AddError(
+					"Invalid 'weight' configured.",
+					fmt.Sprintf("IP '%s', 'weight' must be at least 1.", config.Pools[p].Addresses[a].IP.ValueString()),
+				)
+				return
+			}
 			if err := ValidateIPAddress(config.Pools[p].Addresses[a].IP.ValueString()); err {
 				resp.Diagnostics.AddError(
 					"Invalid 'ip' configured.",
@@ -253,18 +599,112 @@ func (p *ipamProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 				return
 			}
 		}
+		for name, threshold := range map[string]types.Int64{"warn_threshold": config.Pools[p].WarnThreshold, "error_threshold": config.Pools[p].ErrorThreshold} {
+			if !threshold.IsNull() && (threshold.ValueInt64() < 1 || threshold.ValueInt64() > 100) {
+				resp.Diagnostics.AddError(
+					fmt.Sprintf("Invalid '%s' configured.", name),
+					fmt.Sprintf("'%s' of pool '%s' must be a number between 1 and 100.", name, config.Pools[p].Name.ValueString()),
+				)
+				return
+			}
+		}
+		for _, overflow := range config.Pools[p].OverflowPools {
+			if !poolNames[overflow.ValueString()] || overflow.Equal(config.Pools[p].Name) {
+				resp.Diagnostics.AddError(
+					"Invalid 'overflow_pools' configured.",
+					fmt.Sprintf("Overflow pool '%s' of pool '%s' must be another configured pool.", overflow.ValueString(), config.Pools[p].Name.ValueString()),
+				)
+				return
+			}
+			if owner, ok := overflowOwners[overflow.ValueString()]; ok && owner != config.Pools[p].Name.ValueString() {
+				resp.Diagnostics.AddError(
+					"Invalid 'overflow_pools' configured.",
+					fmt.Sprintf("Overflow pool '%s' of pool '%s' is already an overflow pool of '%s'.", overflow.ValueString(), config.Pools[p].Name.ValueString(), owner),
+				)
+				return
+			}
+			overflowOwners[overflow.ValueString()] = config.Pools[p].Name.ValueString()
+		}
+		if !config.Pools[p].DnsTtl.IsNull() && config.Pools[p].DnsTtl.ValueInt64() < 1 {
+			resp.Diagnostics.AddError(
+				"Invalid 'dns_ttl' configured.",
+				fmt.Sprintf("'dns_ttl' of pool '%s' must be at least 1.", config.Pools[p].Name.ValueString()),
+			)
+			return
+		}
+		if config.Pools[p].HostOffset != nil {
+			if _, err := newHostOffset(config.Pools[p].HostOffset); err != nil {
+				resp.Diagnostics.AddError(
+					"Invalid 'host_offset' configured.",
+					fmt.Sprintf("'host_offset' of pool '%s': %s.", config.Pools[p].Name.ValueString(), err.Error()),
+				)
+				return
+			}
+		}
+		if !config.Pools[p].DnsNameTemplate.IsNull() && !strings.Contains(config.Pools[p].DnsNameTemplate.ValueString(), "{host}") {
+			resp.Diagnostics.AddError(
+				"Invalid 'dns_name_template' configured.",
+				fmt.Sprintf("'dns_name_template' of pool '%s' must contain '{host}'.", config.Pools[p].Name.ValueString()),
+			)
+			return
+		}
 	}
 
-	resp.DataSourceData = config.Pools
-	resp.ResourceData = config.Pools
+	if config.DnsUpdate != nil {
+		updater, err := newDnsUpdater(config.DnsUpdate)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid 'dns_update' configured.",
+				fmt.Sprintf("'dns_update': %s.", err.Error()),
+			)
+			return
+		}
+		config.DnsUpdater = updater
+	}
+
+	for p := range config.PrefixPools {
+		for _, prefix := range config.PrefixPools[p].Prefixes {
+			if err := ValidatePrefix(prefix.ValueString()); err {
+				resp.Diagnostics.AddError(
+					"Invalid 'prefixes' configured.",
+					fmt.Sprintf("Prefix '%s' is not a valid network prefix in CIDR notation.", prefix.ValueString()),
+				)
+				return
+			}
+		}
+	}
+
+	resp.DataSourceData = &config
+	resp.ResourceData = &config
 }
 
 func (p *ipamProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewIpamAllocateResource,
+		NewIpamPrefixAllocateResource,
+		NewIpamLinkAllocateResource,
+		NewIpamGroupAllocateResource,
 	}
 }
 
 func (p *ipamProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewIpamDhcpReservationsDataSource,
+		NewIpamDnsRecordsDataSource,
+		NewIpamNetworkConfigDataSource,
+		NewIpamLbPoolsDataSource,
+		NewIpamPrefixSummaryDataSource,
+		NewIpamPoolPrefixesDataSource,
+	}
+}
+
+func (p *ipamProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewRangeContainsFunction,
+		NewIpAddFunction,
+		NewIpDiffFunction,
+		NewRangeSizeFunction,
+		NewNormalizeFunction,
+		NewInPoolFunction,
+	}
 }
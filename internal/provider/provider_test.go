@@ -1,6 +1,15 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 )
@@ -31,6 +40,23 @@ provider "ipam" {
 					ip            = "1.1.1.11"
 				},
 			]
+		},
+		{
+			name          = "POOL2"
+			prefix_length = 24
+			gateway       = "10.1.0.254"
+			ranges = [
+				{
+					from_ip = "10.1.0.1"
+					to_ip   = "10.1.0.15"
+				}
+			]
+		}
+	]
+	prefix_pools = [
+		{
+			name     = "PREFIX_POOL1"
+			prefixes = ["10.0.0.0/24", "10.0.1.0/24"]
 		}
 	]
 }
@@ -46,3 +72,40 @@ var (
 		"ipam": providerserver.NewProtocol6WithError(New()),
 	}
 )
+
+// testAccPreCheckFunctions skips tests of provider functions, which require
+// Terraform 1.8 or later.
+func testAccPreCheckFunctions(t *testing.T) {
+	path := os.Getenv("TF_ACC_TERRAFORM_PATH")
+	if path == "" {
+		path = "terraform"
+	}
+	out, err := exec.Command(path, "version", "-json").Output()
+	if err != nil {
+		return
+	}
+	var v struct {
+		TerraformVersion string `json:"terraform_version"`
+	}
+	if json.Unmarshal(out, &v) != nil {
+		return
+	}
+	if current, err := version.NewVersion(v.TerraformVersion); err == nil && current.LessThan(version.Must(version.NewVersion("1.8.0"))) {
+		t.Skipf("Provider functions require Terraform 1.8 or later, found %s", v.TerraformVersion)
+	}
+}
+
+// runTestFunction runs a provider function with the given arguments.
+func runTestFunction(t *testing.T, f function.Function, args ...attr.Value) (attr.Value, *function.FuncError) {
+	t.Helper()
+	ctx := context.Background()
+	var definition function.DefinitionResponse
+	f.Definition(ctx, function.DefinitionRequest{}, &definition)
+	result, err := definition.Definition.Return.NewResultData(ctx)
+	if err != nil {
+		t.Fatalf("NewResultData() error = %v", err)
+	}
+	resp := function.RunResponse{Result: result}
+	f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData(args)}, &resp)
+	return resp.Result.Value(), resp.Error
+}
@@ -3,26 +3,41 @@ package provider
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math/rand"
+	"net/netip"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
 )
 
 var _ resource.Resource = (*ipamAllocateResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*ipamAllocateResource)(nil)
+var _ resource.ResourceWithUpgradeState = (*ipamAllocateResource)(nil)
 
 func NewIpamAllocateResource() resource.Resource {
 	return &ipamAllocateResource{}
 }
 
 type ipamAllocateResource struct {
-	pools []providerDataPool
+	pools       []providerDataPool
+	assignments []providerAssignment
+	dns         *dnsUpdater
 }
 
 func (r *ipamAllocateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -31,8 +46,9 @@ func (r *ipamAllocateResource) Metadata(_ context.Context, req resource.Metadata
 
 func (r *ipamAllocateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: 1,
 		// This description is used by the documentation generator and the language server.
-		MarkdownDescription: "Allocate one IP from a pool per unique host ID. A single resource must be used per pool.",
+		MarkdownDescription: "Allocate one or more IPs from a pool per unique host ID. A single resource must be used per pool.",
 
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -43,18 +59,54 @@ func (r *ipamAllocateResource) Schema(ctx context.Context, req resource.SchemaRe
 				Description: "Pool name. Must reference a pool from the provider configuration.",
 				Required:    true,
 			},
+			"strategy": schema.StringAttribute{
+				MarkdownDescription: "Selection of new addresses, either `first_free`, `last_free` or `random`, which selects addresses proportionally to their `weight`. Addresses with a higher `priority` are always selected first. Defaults to `first_free`.",
+				Optional:            true,
+			},
+			"renames": schema.MapAttribute{
+				MarkdownDescription: "A map of old host IDs and their new host IDs. The addresses of a renamed host are transferred to its new host ID instead of allocating new ones, e.g. `{ leaf1 = \"leaf-101\" }`. Each new host ID can only be the target of a single rename. Entries can be removed once applied.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
 			"hosts": schema.MapNestedAttribute{
 				Description: "A map of host IDs and its assigned addresses.",
 				Required:    true,
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
+						"count": schema.Int64Attribute{
+							MarkdownDescription: "Number of IP addresses to allocate. Existing addresses are kept when the number grows and the last addresses are released when it shrinks.",
+							Optional:            true,
+							Computed:            true,
+							Default:             int64default.StaticInt64(1),
+						},
+						"contiguous": schema.BoolAttribute{
+							MarkdownDescription: "Allocate consecutive IP addresses. Blocks of consecutive addresses may span ranges of different priorities, blocks whose addresses all have a higher `priority` are preferred.",
+							Optional:            true,
+						},
+						"affinity_group": schema.StringAttribute{
+							MarkdownDescription: "Hosts with the same affinity group are allocated from different ranges if possible, e.g. redundant pairs like `spine1` and `spine2`. Standalone addresses with the same prefix length and gateway count as one range. If the pool consists of a single range the hosts alternate between every other address of the range, counting only the addresses selected by its `step` and `align`. A warning is shown if hosts of a group share a range.",
+							Optional:            true,
+						},
+						"selector": schema.MapAttribute{
+							MarkdownDescription: "Only allocate IP addresses of ranges and addresses whose `labels` contain all of these labels, e.g. `{ rack = \"r12\" }`. Existing addresses are kept when the selector changes.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
 						"ip": schema.StringAttribute{
-							MarkdownDescription: "IP address.",
+							MarkdownDescription: "First IP address.",
 							Computed:            true,
 							PlanModifiers: []planmodifier.String{
 								stringplanmodifier.UseStateForUnknown(),
 							},
 						},
+						"ips": schema.ListAttribute{
+							MarkdownDescription: "All IP addresses.",
+							ElementType:         types.StringType,
+							Computed:            true,
+							PlanModifiers: []planmodifier.List{
+								listplanmodifier.UseStateForUnknown(),
+							},
+						},
 						"prefix_length": schema.Int64Attribute{
 							MarkdownDescription: "Prefix length.",
 							Computed:            true,
@@ -69,6 +121,13 @@ func (r *ipamAllocateResource) Schema(ctx context.Context, req resource.SchemaRe
 								stringplanmodifier.UseStateForUnknown(),
 							},
 						},
+						"pool": schema.StringAttribute{
+							MarkdownDescription: "Pool of the first IP address, which differs from `pool` if the address was allocated from one of its `overflow_pools`.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
 					},
 				},
 			},
@@ -76,75 +135,198 @@ func (r *ipamAllocateResource) Schema(ctx context.Context, req resource.SchemaRe
 	}
 }
 
-type Allocate struct {
-	Id    types.String            `tfsdk:"id"`
-	Pool  types.String            `tfsdk:"pool"`
-	Hosts map[string]AllocateHost `tfsdk:"hosts"`
+// allocateV0 is the state of version 0.1.0 with a single IP per host.
+type allocateV0 struct {
+	Id    types.String              `tfsdk:"id"`
+	Pool  types.String              `tfsdk:"pool"`
+	Hosts map[string]allocateHostV0 `tfsdk:"hosts"`
 }
 
-type AllocateHost struct {
+type allocateHostV0 struct {
 	Ip           types.String `tfsdk:"ip"`
 	PrefixLength types.Int64  `tfsdk:"prefix_length"`
 	Gateway      types.String `tfsdk:"gateway"`
 }
 
+func (r *ipamAllocateResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: &schema.Schema{
+				Attributes: map[string]schema.Attribute{
+					"id": schema.StringAttribute{
+						Computed: true,
+					},
+					"pool": schema.StringAttribute{
+						Required: true,
+					},
+					"hosts": schema.MapNestedAttribute{
+						Required: true,
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"ip": schema.StringAttribute{
+									Computed: true,
+								},
+								"prefix_length": schema.Int64Attribute{
+									Computed: true,
+								},
+								"gateway": schema.StringAttribute{
+									Computed: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			StateUpgrader: r.upgradeStateV0,
+		},
+	}
+}
+
+// upgradeStateV0 adds the count and list of IPs of hosts introduced after
+// version 0.1.0 with the values matching their single IP, so that existing
+// resources have no changes. All other attributes are null.
+func (r *ipamAllocateResource) upgradeStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior allocateV0
+
+	diags := req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for name, value := range map[string]interface{}{"id": prior.Id, "pool": prior.Pool, "hosts": map[string]AllocateHost{}} {
+		diags = resp.State.SetAttribute(ctx, path.Root(name), value)
+		resp.Diagnostics.Append(diags...)
+	}
+	for h, a := range prior.Hosts {
+		ips := types.ListNull(types.StringType)
+		if !a.Ip.IsNull() {
+			ips = types.ListValueMust(types.StringType, []attr.Value{a.Ip})
+		}
+		for name, value := range map[string]attr.Value{"count": types.Int64Value(1), "ip": a.Ip, "ips": ips, "prefix_length": a.PrefixLength, "gateway": a.Gateway} {
+			diags = resp.State.SetAttribute(ctx, path.Root("hosts").AtMapKey(h).AtName(name), value)
+			resp.Diagnostics.Append(diags...)
+		}
+	}
+}
+
+type Allocate struct {
+	Id       types.String            `tfsdk:"id"`
+	Pool     types.String            `tfsdk:"pool"`
+	Hosts    map[string]AllocateHost `tfsdk:"hosts"`
+	Renames  map[string]types.String `tfsdk:"renames"`
+	Strategy types.String            `tfsdk:"strategy"`
+}
+
+type AllocateHost struct {
+	Count         types.Int64  `tfsdk:"count"`
+	Contiguous    types.Bool   `tfsdk:"contiguous"`
+	Selector      types.Map    `tfsdk:"selector"`
+	AffinityGroup types.String `tfsdk:"affinity_group"`
+	Ip            types.String `tfsdk:"ip"`
+	Ips           types.List   `tfsdk:"ips"`
+	PrefixLength  types.Int64  `tfsdk:"prefix_length"`
+	Gateway       types.String `tfsdk:"gateway"`
+	Pool          types.String `tfsdk:"pool"`
+}
+
 func (r *ipamAllocateResource) Configure(ctx context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	r.pools = req.ProviderData.([]providerDataPool)
+	r.pools = req.ProviderData.(*providerData).Pools
+	r.assignments = req.ProviderData.(*providerData).Assignments
+	r.dns = req.ProviderData.(*providerData).DnsUpdater
 }
 
-func (r *ipamAllocateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var plan, state Allocate
+func (r *ipamAllocateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
 
-	// Read plan
-	diags := req.Plan.Get(ctx, &plan)
-	resp.Diagnostics.Append(diags...)
+	// addresses allocated by overflow are not visible to a resource of the
+	// overflow pool itself
+	var poolName types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("pool"), &poolName)...)
+	if owner := r.overflowOwner(poolName.ValueString()); owner != "" {
+		resp.Diagnostics.AddAttributeError(path.Root("pool"), "Invalid 'pool' configured.", fmt.Sprintf("Pool '%s' is an overflow pool of '%s' and can only be allocated from through '%s'.", poolName.ValueString(), owner, owner))
+	}
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	tflog.Debug(ctx, fmt.Sprintf("Beginning Create"))
+	resp.Diagnostics.Append(r.checkThresholds(ctx, req.Plan, req.State)...)
+	if resp.Diagnostics.HasError() || req.State.Raw.IsNull() {
+		return
+	}
 
-	var pool *providerDataPool
+	var plan, state Allocate
 
-	for i := range r.pools {
-		if r.pools[i].Name.ValueString() == plan.Pool.ValueString() {
-			pool = &r.pools[i]
-		}
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	if pool == nil {
-		resp.Diagnostics.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", plan.Pool.ValueString()))
+
+	renamed, diags := getRenamedHosts(&plan, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	poolAddresses := GetAddressesFromPool(pool)
+	// a changed count requires a new list of addresses
+	for h, a := range plan.Hosts {
+		s, ok := state.Hosts[renamed[h]]
+		if renamed[h] != "" {
+			// show transferred addresses of renamed hosts
+			for name, value := range map[string]attr.Value{"ip": s.Ip, "ips": s.Ips, "prefix_length": s.PrefixLength, "gateway": s.Gateway, "pool": s.Pool} {
+				diags = resp.Plan.SetAttribute(ctx, path.Root("hosts").AtMapKey(h).AtName(name), value)
+				resp.Diagnostics.Append(diags...)
+			}
+		} else {
+			s, ok = state.Hosts[h]
+		}
+		if !ok || a.Count.Equal(s.Count) {
+			continue
+		}
+		diags = resp.Plan.SetAttribute(ctx, path.Root("hosts").AtMapKey(h).AtName("ips"), types.ListUnknown(types.StringType))
+		resp.Diagnostics.Append(diags...)
+	}
+}
 
-	hosts := plan.Hosts
+func (r *ipamAllocateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan, state Allocate
 
-	if len(hosts) > len(poolAddresses) {
-		resp.Diagnostics.AddError("Not enough IPs in pool", fmt.Sprintf("Pool '%s' does not have enough IP addresses.", plan.Pool.ValueString()))
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	index := 0
-	for h, _ := range hosts {
-		ip := poolAddresses[index].IP
-		prefixLength := poolAddresses[index].PrefixLength
-		gateway := poolAddresses[index].Gateway
-		hosts[h] = AllocateHost{Ip: ip, PrefixLength: prefixLength, Gateway: gateway}
-		tflog.Debug(ctx, fmt.Sprintf("Allocate IP to %s: %v", h, ip.ValueString()))
-		index += 1
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Create"))
+
+	diags = r.allocate(ctx, &plan, nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	state.Pool = plan.Pool
-	state.Hosts = hosts
+	state.Hosts = plan.Hosts
+	state.Renames = plan.Renames
+	state.Strategy = plan.Strategy
 
 	rand.Seed(time.Now().UnixNano())
 	state.Id = types.StringValue(fmt.Sprint(rand.Int63()))
 
+	// the allocated addresses are saved even if DNS updates fail
+	diags = r.updateDns(ctx, nil, &state)
+	resp.Diagnostics.Append(diags...)
+
 	tflog.Debug(ctx, fmt.Sprintf("Create finished successfully"))
 
 	diags = resp.State.Set(ctx, &state)
@@ -163,6 +345,20 @@ func (r *ipamAllocateResource) Read(ctx context.Context, req resource.ReadReques
 
 	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
 
+	// hosts of states upgraded from version 0.1.0 have no pool
+	var poolNames map[netip.Addr]string
+	for h, a := range state.Hosts {
+		if !a.Pool.IsNull() || a.Ip.IsNull() {
+			continue
+		}
+		if poolNames == nil {
+			poolNames = r.chainAddressPools(state.Pool.ValueString())
+		}
+		ip, _ := netip.ParseAddr(a.Ip.ValueString())
+		a.Pool = hostPool(poolNames, ip)
+		state.Hosts[h] = a
+	}
+
 	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
 
 	diags = resp.State.Set(ctx, &state)
@@ -170,7 +366,7 @@ func (r *ipamAllocateResource) Read(ctx context.Context, req resource.ReadReques
 }
 
 func (r *ipamAllocateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var plan, state Allocate
+	var plan, prior, state Allocate
 
 	// Read plan
 	diags := req.Plan.Get(ctx, &plan)
@@ -179,8 +375,65 @@ func (r *ipamAllocateResource) Update(ctx context.Context, req resource.UpdateRe
 		return
 	}
 
+	// Read prior state
+	diags = req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	tflog.Debug(ctx, fmt.Sprintf("Beginning Update"))
 
+	diags = r.allocate(ctx, &plan, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Id = types.StringValue(plan.Id.ValueString())
+	state.Pool = plan.Pool
+	state.Hosts = plan.Hosts
+	state.Renames = plan.Renames
+	state.Strategy = plan.Strategy
+
+	// the allocated addresses are saved even if DNS updates fail
+	diags = r.updateDns(ctx, &prior, &state)
+	resp.Diagnostics.Append(diags...)
+
+	tflog.Debug(ctx, fmt.Sprintf("Update finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamAllocateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state Allocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Delete"))
+
+	diags = r.updateDns(ctx, &state, nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Delete finished successfully"))
+
+	resp.State.RemoveResource(ctx)
+}
+
+// allocate assigns the requested number of addresses to every host, keeping
+// all existing addresses from the plan or the prior state.
+func (r *ipamAllocateResource) allocate(ctx context.Context, plan, prior *Allocate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
 	var pool *providerDataPool
 
 	for i := range r.pools {
@@ -189,75 +442,416 @@ func (r *ipamAllocateResource) Update(ctx context.Context, req resource.UpdateRe
 		}
 	}
 	if pool == nil {
-		resp.Diagnostics.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", plan.Pool.ValueString()))
-		return
+		diags.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", plan.Pool.ValueString()))
+		return diags
 	}
 
-	poolAddresses := GetAddressesFromPool(pool)
+	chainPools := r.poolChain(pool)
+	var overflow []*ipam.Pool
+	for _, p := range chainPools[1:] {
+		overflow = append(overflow, ToIpamPool(p))
+	}
 
-	hosts := plan.Hosts
+	var offset *hostOffset
+	var poolAddresses []ipam.Address
+	if pool.HostOffset != nil {
+		var err error
+		if offset, err = newHostOffset(pool.HostOffset); err != nil {
+			diags.AddError("Invalid 'host_offset' configured.", fmt.Sprintf("'host_offset' of pool '%s': %s.", pool.Name.ValueString(), err.Error()))
+			return diags
+		}
+		poolAddresses = ToIpamPool(pool).ExpandDeclared()
+	}
 
-	if len(hosts) > len(poolAddresses) {
-		resp.Diagnostics.AddError("Not enough IPs in pool", fmt.Sprintf("Pool '%s' does not have enough IP addresses.", plan.Pool.ValueString()))
-		return
+	hosts := plan.Hosts
+	renamed, renameDiags := getRenamedHosts(plan, prior)
+	diags.Append(renameDiags...)
+	if diags.HasError() {
+		return diags
 	}
 
+	// get list of assigned addresses
+	requests := make(map[string]ipam.Request, len(hosts))
+	existing := make(map[string][]netip.Addr, len(hosts))
 	for h, a := range hosts {
-		// check if an address is already assigned
-		if a.Ip.ValueString() != "" {
-			continue
+		if a.Count.ValueInt64() < 1 {
+			diags.AddError("Invalid 'count' configured.", fmt.Sprintf("'count' of '%s' must be at least 1.", h))
+			return diags
 		}
-		// get list of assigned addresses
-		var inUseAddresses []string
-		for _, a := range hosts {
-			if a.Ip.ValueString() != "" {
-				inUseAddresses = append(inUseAddresses, a.Ip.ValueString())
-			}
+		selector := make(map[string]string)
+		diags.Append(a.Selector.ElementsAs(ctx, &selector, false)...)
+		if diags.HasError() {
+			return diags
 		}
-		// find next free IP
-		for pa := range poolAddresses {
-			inUse := false
-			for _, inUseAddress := range inUseAddresses {
-				if poolAddresses[pa].IP.ValueString() == inUseAddress {
-					inUse = true
-					break
+		request := ipam.Request{Count: int(a.Count.ValueInt64()), Contiguous: a.Contiguous.ValueBool(), Match: selectorMatch(chainPools, selector), AffinityGroup: a.AffinityGroup.ValueString()}
+		if offset != nil {
+			if position, ok := offset.Position(h); ok {
+				if position < 0 || position >= int64(len(poolAddresses)) {
+					if offset.strict {
+						diags.AddError("Invalid host offset", fmt.Sprintf("Position %d derived from '%s' is outside of pool '%s'.", position, h, plan.Pool.ValueString()))
+						return diags
+					}
+				} else {
+					request.Preferred = poolAddresses[position].IP
+					request.Strict = offset.strict
 				}
 			}
-			if inUse {
-				continue
+		}
+		requests[h] = request
+		ips, ip := a.Ips, a.Ip
+		if old, ok := renamed[h]; ok {
+			// transfer addresses of renamed hosts
+			if ip.ValueString() == "" {
+				ip = prior.Hosts[old].Ip
+			}
+			if ips.IsUnknown() {
+				ips = prior.Hosts[old].Ips
+			}
+			tflog.Debug(ctx, fmt.Sprintf("Transfer IPs of %s to %s", old, h))
+		} else if ips.IsUnknown() && prior != nil {
+			ips = prior.Hosts[h].Ips
+		}
+		var addresses []string
+		if !ips.IsNull() && !ips.IsUnknown() {
+			diags.Append(ips.ElementsAs(ctx, &addresses, false)...)
+			if diags.HasError() {
+				return diags
+			}
+		} else if ip.ValueString() != "" {
+			addresses = []string{ip.ValueString()}
+		}
+		for _, address := range addresses {
+			if addr, err := netip.ParseAddr(address); err == nil {
+				existing[h] = append(existing[h], addr)
 			}
-			ip := poolAddresses[pa].IP
-			prefixLength := poolAddresses[pa].PrefixLength
-			gateway := poolAddresses[pa].Gateway
-			hosts[h] = AllocateHost{Ip: ip, PrefixLength: prefixLength, Gateway: gateway}
-			tflog.Debug(ctx, fmt.Sprintf("Allocate IP to %s: %v", h, ip.ValueString()))
-			break
 		}
 	}
 
-	state.Id = types.StringValue(plan.Id.ValueString())
-	state.Pool = plan.Pool
-	state.Hosts = hosts
+	// adopt known assignments of new hosts and reserve all others
+	ipamPool := ToIpamPool(pool)
+	reserved, rejected := adoptAssignments(r.assignments, append([]*ipam.Pool{ipamPool}, overflow...), requests, existing)
+	for _, a := range rejected {
+		diags.AddWarning("Known assignment not adopted", fmt.Sprintf("Address '%s' of host '%s' from 'assignments_csv' is not an address of pool '%s' or its overflow pools matching the host. A new address is allocated instead.", a.IP.String(), a.Host, plan.Pool.ValueString()))
+	}
 
-	tflog.Debug(ctx, fmt.Sprintf("Update finished successfully"))
+	allocator := ipam.Allocator{Pool: ipamPool, Reserved: reserved, Overflow: overflow}
+	switch plan.Strategy.ValueString() {
+	case "", "first_free":
+	case "last_free":
+		allocator.Strategy = ipam.LastFree{}
+	case "random":
+		allocator.Strategy = ipam.Random{}
+	default:
+		diags.AddError("Invalid 'strategy' configured.", "'strategy' must be either 'first_free', 'last_free' or 'random'.")
+		return diags
+	}
+	allocations, err := allocator.Allocate(requests, existing)
+	if err != nil {
+		AddAllocationError(&diags, err)
+		return diags
+	}
+	for _, c := range allocator.AffinityConflicts(requests, allocations) {
+		diags.AddWarning("Affinity group not satisfied", fmt.Sprintf("Hosts '%s' of affinity group '%s' share a range of pool '%s', as there are not enough ranges with free addresses.", strings.Join(c.Hosts, "', '"), c.Group, plan.Pool.ValueString()))
+	}
 
-	diags = resp.State.Set(ctx, &state)
-	resp.Diagnostics.Append(diags...)
+	poolNames := addressPools(append([]*ipam.Pool{allocator.Pool}, overflow...))
+	for h, addresses := range allocations {
+		a := hosts[h]
+		ips := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			ips = append(ips, address.IP.String())
+		}
+		ipList, d := types.ListValueFrom(ctx, types.StringType, ips)
+		diags.Append(d...)
+		if diags.HasError() {
+			return diags
+		}
+		a.Ips = ipList
+		switch {
+		case a.Ip.ValueString() != ips[0] || a.PrefixLength.IsUnknown() || a.Gateway.IsUnknown():
+			a.Ip = addressIP(addresses[0])
+			a.PrefixLength = addressPrefixLength(addresses[0])
+			a.Gateway = addressGateway(addresses[0])
+			a.Pool = hostPool(poolNames, addresses[0].IP)
+		case a.Pool.IsUnknown():
+			a.Pool = hostPool(poolNames, addresses[0].IP)
+		}
+		hosts[h] = a
+		if len(addresses) > len(existing[h]) {
+			tflog.Debug(ctx, fmt.Sprintf("Allocate IPs to %s: %v", h, ips[len(existing[h]):]))
+		}
+	}
+
+	return diags
 }
 
-func (r *ipamAllocateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	var state Allocate
+// poolChain returns the pool followed by its overflow pools in order, each
+// pool at most once.
+func (r *ipamAllocateResource) poolChain(pool *providerDataPool) []*providerDataPool {
+	chain := map[string]bool{pool.Name.ValueString(): true}
+	pools := []*providerDataPool{pool}
+	for _, name := range pool.OverflowPools {
+		for i := range r.pools {
+			if r.pools[i].Name.Equal(name) && !chain[name.ValueString()] {
+				pools = append(pools, &r.pools[i])
+				chain[name.ValueString()] = true
+			}
+		}
+	}
+	return pools
+}
 
-	// Read state
-	diags := req.State.Get(ctx, &state)
-	resp.Diagnostics.Append(diags...)
-	if resp.Diagnostics.HasError() {
-		return
+// overflowOwner returns the name of the pool which overflows into the pool
+// name, or an empty string if it is not an overflow pool.
+func (r *ipamAllocateResource) overflowOwner(name string) string {
+	for _, p := range r.pools {
+		for _, overflow := range p.OverflowPools {
+			if overflow.ValueString() == name {
+				return p.Name.ValueString()
+			}
+		}
 	}
+	return ""
+}
 
-	tflog.Debug(ctx, fmt.Sprintf("Beginning Delete"))
+// chainAddressPools returns the name of the pool of each address of a pool
+// and its overflow pools.
+func (r *ipamAllocateResource) chainAddressPools(name string) map[netip.Addr]string {
+	var chain []*ipam.Pool
+	for i := range r.pools {
+		if r.pools[i].Name.ValueString() == name {
+			for _, p := range r.poolChain(&r.pools[i]) {
+				chain = append(chain, ToIpamPool(p))
+			}
+		}
+	}
+	return addressPools(chain)
+}
 
-	tflog.Debug(ctx, fmt.Sprintf("Delete finished successfully"))
+// hostPool returns the name of the pool containing ip or null if it is not
+// part of any pool.
+func hostPool(poolNames map[netip.Addr]string, ip netip.Addr) types.String {
+	if name, ok := poolNames[ip]; ok {
+		return types.StringValue(name)
+	}
+	return types.StringNull()
+}
 
-	resp.State.RemoveResource(ctx)
+// checkThresholds reports the utilization of the pool and its overflow pools
+// after applying the plan if it changes and exceeds the thresholds of a pool.
+// The plan is allocated to find the pool of every address, allocation errors
+// are reported as well. Addresses of known assignments
+// of other hosts are counted as allocated, addresses of other resources are
+// not.
+func (r *ipamAllocateResource) checkThresholds(ctx context.Context, plan tfsdk.Plan, state tfsdk.State) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var poolName types.String
+	var hostsMap, renamesMap types.Map
+	diags.Append(plan.GetAttribute(ctx, path.Root("pool"), &poolName)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("hosts"), &hostsMap)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("renames"), &renamesMap)...)
+	if diags.HasError() || poolName.IsUnknown() || hostsMap.IsUnknown() || renamesMap.IsUnknown() {
+		return diags
+	}
+
+	var pool *providerDataPool
+	for i := range r.pools {
+		if r.pools[i].Name.ValueString() == poolName.ValueString() {
+			pool = &r.pools[i]
+		}
+	}
+	if pool == nil {
+		return diags
+	}
+	chain := r.poolChain(pool)
+	thresholds := false
+	for _, p := range chain {
+		thresholds = thresholds || !p.WarnThreshold.IsNull() || !p.ErrorThreshold.IsNull()
+	}
+	if !thresholds {
+		return diags
+	}
+
+	var planned Allocate
+	diags.Append(plan.Get(ctx, &planned)...)
+	if diags.HasError() {
+		return diags
+	}
+	for _, a := range planned.Hosts {
+		if a.Count.IsUnknown() {
+			return diags
+		}
+	}
+	var prior *Allocate
+	if !state.Raw.IsNull() {
+		prior = &Allocate{}
+		diags.Append(state.Get(ctx, prior)...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	planned.Hosts = maps.Clone(planned.Hosts)
+	diags.Append(r.allocate(ctx, &planned, prior)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	ipamChain := make([]*ipam.Pool, 0, len(chain))
+	names := make(map[string]bool, len(chain))
+	for _, p := range chain {
+		ipamChain = append(ipamChain, ToIpamPool(p))
+		names[p.Name.ValueString()] = true
+	}
+	poolNames := addressPools(ipamChain)
+
+	// addresses in use per pool before and after applying the plan
+	used := make(map[string]map[netip.Addr]bool, len(chain))
+	previous := make(map[string]map[netip.Addr]bool, len(chain))
+	for _, p := range chain {
+		used[p.Name.ValueString()] = make(map[netip.Addr]bool)
+		previous[p.Name.ValueString()] = make(map[netip.Addr]bool)
+	}
+	addHosts := func(inUse map[string]map[netip.Addr]bool, hosts map[string]AllocateHost) {
+		for _, a := range hosts {
+			var ips []string
+			if a.Ips.IsNull() || a.Ips.IsUnknown() || a.Ips.ElementsAs(ctx, &ips, false).HasError() {
+				continue
+			}
+			for _, ip := range ips {
+				if addr, err := netip.ParseAddr(ip); err == nil && poolNames[addr] != "" {
+					inUse[poolNames[addr]][addr] = true
+				}
+			}
+		}
+	}
+	addHosts(used, planned.Hosts)
+	if prior != nil {
+		addHosts(previous, prior.Hosts)
+	}
+	for _, a := range r.assignments {
+		if a.Pool != "" && !names[a.Pool] {
+			continue
+		}
+		if _, ok := planned.Hosts[a.Host]; !ok && poolNames[a.IP] != "" {
+			used[poolNames[a.IP]][a.IP] = true
+			previous[poolNames[a.IP]][a.IP] = true
+		}
+	}
+
+	for i, p := range chain {
+		size := 0
+		for _, a := range ipamChain[i].Expand() {
+			if poolNames[a.IP] == p.Name.ValueString() {
+				size++
+			}
+		}
+		AddThresholdDiags(&diags, p, len(used[p.Name.ValueString()]), len(previous[p.Name.ValueString()]), size)
+	}
+	return diags
+}
+
+// updateDns sends dynamic DNS updates for all records which differ between
+// the hosts of before and after. Failures are reported as warnings unless
+// 'on_failure' is set to 'error'.
+func (r *ipamAllocateResource) updateDns(ctx context.Context, before, after *Allocate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.dns == nil {
+		return diags
+	}
+
+	updates, err := getDnsUpdates(r.dnsRRsets(ctx, before), r.dnsRRsets(ctx, after))
+	if err == nil && len(updates) > 0 {
+		tflog.Debug(ctx, fmt.Sprintf("Send %d DNS updates", len(updates)))
+		err = r.dns.Send(ctx, updates)
+	}
+	if err != nil {
+		if r.dns.onFailure == "error" {
+			diags.AddError("DNS update failed", err.Error())
+		} else {
+			diags.AddWarning("DNS update failed", err.Error())
+		}
+	}
+
+	return diags
+}
+
+// dnsRRsets returns the record sets of all hosts in the zone of the pool of
+// their address, which differs from the pool of the resource for hosts
+// allocated from an overflow pool.
+func (r *ipamAllocateResource) dnsRRsets(ctx context.Context, a *Allocate) map[dnsRRset]dnsRecordSet {
+	if a == nil {
+		return nil
+	}
+	hosts := make(map[string]map[string]AllocateHost)
+	for h, host := range a.Hosts {
+		pool := a.Pool.ValueString()
+		if !host.Pool.IsNull() && !host.Pool.IsUnknown() {
+			pool = host.Pool.ValueString()
+		}
+		if hosts[pool] == nil {
+			hosts[pool] = make(map[string]AllocateHost)
+		}
+		hosts[pool][h] = host
+	}
+	rrsets := make(map[dnsRRset]dnsRecordSet)
+	for i := range r.pools {
+		pool := &r.pools[i]
+		if _, ok := hosts[pool.Name.ValueString()]; !ok || pool.DnsZone.IsNull() {
+			continue
+		}
+		for k, v := range dnsPoolRRsets(pool, dnsHostsFromAllocations(ctx, hosts[pool.Name.ValueString()])) {
+			v.Values = append(rrsets[k].Values, v.Values...)
+			rrsets[k] = v
+		}
+	}
+	return rrsets
+}
+
+// getRenamedHosts returns a map of new host IDs and their old host IDs for
+// all renames where the old host ID only exists in the prior state. Renaming
+// several hosts to the same host ID is an error.
+func getRenamedHosts(plan, prior *Allocate) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	renamed := make(map[string]string)
+
+	olds := make([]string, 0, len(plan.Renames))
+	for old := range plan.Renames {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	targets := make(map[string]string, len(olds))
+	for _, old := range olds {
+		if plan.Renames[old].IsUnknown() || plan.Renames[old].IsNull() {
+			continue
+		}
+		h := plan.Renames[old].ValueString()
+		if other, ok := targets[h]; ok {
+			diags.AddError("Invalid 'renames' configured.", fmt.Sprintf("'%s' and '%s' are both renamed to '%s'.", other, old, h))
+			continue
+		}
+		targets[h] = old
+	}
+	if diags.HasError() || prior == nil {
+		return renamed, diags
+	}
+
+	for old, h := range plan.Renames {
+		if _, ok := plan.Hosts[old]; ok {
+			continue
+		}
+		if _, ok := plan.Hosts[h.ValueString()]; !ok {
+			continue
+		}
+		if _, ok := prior.Hosts[old]; !ok {
+			continue
+		}
+		if _, ok := prior.Hosts[h.ValueString()]; ok {
+			continue
+		}
+		renamed[h.ValueString()] = old
+	}
+	return renamed, diags
 }
@@ -1,9 +1,20 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccIpamAllocate(t *testing.T) {
@@ -95,3 +106,1071 @@ func testAccIpamAllocateConfig_update3() string {
 	}
 	`
 }
+
+func TestAccIpamAllocateCount(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamAllocateCountConfig_initial(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.1.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.#", "2"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.1", "10.1.0.2"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ips.#", "1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ips.0", "10.1.0.3"),
+				),
+			},
+			{
+				Config: providerConfig + testAccIpamAllocateCountConfig_update1(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.#", "3"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.0", "10.1.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.1", "10.1.0.2"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.2", "10.1.0.7"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ips.0", "10.1.0.3"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ips.#", "3"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ips.0", "10.1.0.4"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ips.2", "10.1.0.6"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateCountConfig_initial() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"host1" = { count = 2 }
+			"host2" = {}
+		}
+	}
+	`
+}
+
+func testAccIpamAllocateCountConfig_update1() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"host1" = { count = 3 }
+			"host2" = {}
+			"host3" = { count = 3, contiguous = true }
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateUpgradeState(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Steps: []resource.TestStep{
+			{
+				ExternalProviders: map[string]resource.ExternalProvider{
+					"ipam": {
+						Source:            "netascode/ipam",
+						VersionConstraint: "0.1.0",
+					},
+				},
+				Config: testAccIpamAllocateConfig_upgradeState(),
+			},
+			{
+				ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+				Config:                   testAccIpamAllocateConfig_upgradeState(),
+				PlanOnly:                 true,
+			},
+			{
+				ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+				Config:                   testAccIpamAllocateConfig_upgradeState(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.count", "1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.0", "10.7.4.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.pool", "UPGRADE_POOL1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_upgradeState() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name          = "UPGRADE_POOL1"
+				prefix_length = 24
+				ranges = [
+					{
+						from_ip = "10.7.4.1"
+						to_ip   = "10.7.4.10"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "UPGRADE_POOL1"
+		hosts = {
+			"host1" = {}
+		}
+	}
+	`
+}
+
+func TestUpgradeStateV0(t *testing.T) {
+	ctx := context.Background()
+	r := &ipamAllocateResource{}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	upgrader := r.UpgradeState(ctx)[0]
+
+	prior := tfsdk.State{Schema: *upgrader.PriorSchema}
+	diags := prior.Set(ctx, &allocateV0{
+		Id:   types.StringValue("1"),
+		Pool: types.StringValue("POOL1"),
+		Hosts: map[string]allocateHostV0{
+			"host1": {Ip: types.StringValue("1.1.1.1"), PrefixLength: types.Int64Value(22), Gateway: types.StringValue("1.1.1.201")},
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("Set() error = %v", diags)
+	}
+	resp := fwresource.UpgradeStateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}}
+	upgrader.StateUpgrader(ctx, fwresource.UpgradeStateRequest{State: &prior}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("upgradeStateV0() error = %v", resp.Diagnostics)
+	}
+
+	var state Allocate
+	if diags := resp.State.Get(ctx, &state); diags.HasError() {
+		t.Fatalf("Get() error = %v", diags)
+	}
+	host := state.Hosts["host1"]
+	if state.Id.ValueString() != "1" || state.Pool.ValueString() != "POOL1" || host.Count.ValueInt64() != 1 || host.Ip.ValueString() != "1.1.1.1" || host.PrefixLength.ValueInt64() != 22 || host.Gateway.ValueString() != "1.1.1.201" {
+		t.Errorf("upgradeStateV0() = %+v", state)
+	}
+	if want := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("1.1.1.1")}); !host.Ips.Equal(want) {
+		t.Errorf("upgradeStateV0() ips = %v, want %v", host.Ips, want)
+	}
+	if !host.Pool.IsNull() || !state.Strategy.IsNull() {
+		t.Errorf("upgradeStateV0() pool = %v, strategy = %v, want null", host.Pool, state.Strategy)
+	}
+}
+
+func TestAccIpamAllocateRenames(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamAllocateRenamesConfig_initial(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf1.ip", "10.1.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf2.ip", "10.1.0.2"),
+				),
+			},
+			{
+				Config: providerConfig + testAccIpamAllocateRenamesConfig_update1(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr("ipam_allocate.test", "hosts.leaf1.ip"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf-101.ip", "10.1.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf-101.gateway", "10.1.0.254"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf2.ip", "10.1.0.2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateRenamesConfig_initial() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		hosts = {
+			"leaf1" = {}
+			"leaf2" = {}
+		}
+	}
+	`
+}
+
+func testAccIpamAllocateRenamesConfig_update1() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		renames = {
+			"leaf1" = "leaf-101"
+		}
+		hosts = {
+			"leaf-101" = {}
+			"leaf2"    = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateRenamesDuplicate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamAllocateRenamesConfig_initial(),
+			},
+			{
+				Config:      providerConfig + testAccIpamAllocateRenamesConfig_duplicate(),
+				ExpectError: regexp.MustCompile("'leaf1' and 'leaf2' are both renamed to 'leaf-101'"),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateRenamesConfig_duplicate() string {
+	return `
+	resource "ipam_allocate" "test" {
+		pool = "POOL2"
+		renames = {
+			"leaf1" = "leaf-101"
+			"leaf2" = "leaf-101"
+		}
+		hosts = {
+			"leaf-101" = {}
+		}
+	}
+	`
+}
+
+func TestGetRenamedHosts(t *testing.T) {
+	prior := &Allocate{Hosts: map[string]AllocateHost{"leaf1": {}, "leaf2": {}, "leaf3": {}}}
+	tests := []struct {
+		name    string
+		renames map[string]string
+		hosts   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "rename",
+			renames: map[string]string{"leaf1": "leaf-101"},
+			hosts:   []string{"leaf-101", "leaf2", "leaf3"},
+			want:    map[string]string{"leaf-101": "leaf1"},
+		},
+		{
+			name:    "old host still planned",
+			renames: map[string]string{"leaf1": "leaf-101"},
+			hosts:   []string{"leaf1", "leaf-101"},
+			want:    map[string]string{},
+		},
+		{
+			name:    "duplicate target",
+			renames: map[string]string{"leaf1": "leaf-101", "leaf2": "leaf-101", "leaf3": "leaf-103"},
+			hosts:   []string{"leaf-101", "leaf-103"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &Allocate{Hosts: make(map[string]AllocateHost), Renames: make(map[string]types.String)}
+			for _, h := range tt.hosts {
+				plan.Hosts[h] = AllocateHost{}
+			}
+			for old, h := range tt.renames {
+				plan.Renames[old] = types.StringValue(h)
+			}
+			got, diags := getRenamedHosts(plan, prior)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("getRenamedHosts() diags = %v, wantErr %v", diags, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getRenamedHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccIpamAllocatePoolsFile(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_poolsFile(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.2.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.prefix_length", "24"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.gateway", "10.2.0.254"),
+					resource.TestCheckResourceAttr("ipam_allocate.inline", "hosts.host1.ip", "10.1.0.1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_poolsFile() string {
+	return `
+	provider "ipam" {
+		pools_file = "testdata/pools.yaml"
+		pools = [
+			{
+				name          = "INLINE_POOL1"
+				prefix_length = 24
+				gateway       = "10.1.0.254"
+				ranges = [
+					{
+						from_ip = "10.1.0.1"
+						to_ip   = "10.1.0.15"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "FILE_POOL1"
+		hosts = {
+			"host1" = {}
+		}
+	}
+
+	resource "ipam_allocate" "inline" {
+		pool = "INLINE_POOL1"
+		hosts = {
+			"host1" = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateDataModelPools(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_dataModelPools(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.10.0.10"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.prefix_length", "24"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.gateway", "10.10.0.1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_dataModelPools() string {
+	return `
+	provider "ipam" {
+		data_model_pools = [
+			{
+				file             = "testdata/data_model.yaml"
+				path             = "apic.tenants.bridge_domains"
+				cidr_field       = "subnets.ip"
+				gateway_field    = "gateway"
+				exclusions_field = "exclusions"
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "WEB"
+		hosts = {
+			"host1" = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateCsv(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_csv(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.4.0.5"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.prefix_length", "24"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.gateway", "10.4.0.254"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ip", "10.4.0.3"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.legacy2.ip", "10.4.0.2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_csv() string {
+	return `
+	provider "ipam" {
+		pools_csv       = "testdata/pools.csv"
+		assignments_csv = "testdata/assignments.csv"
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "CSV_POOL1"
+		hosts = {
+			"host1"   = {}
+			"host2"   = {}
+			"legacy2" = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateDnsUpdate(t *testing.T) {
+	server := startTestDnsServer(t)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_dnsUpdate(server.addr, 60),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.5.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ip", "10.5.1.1"),
+					func(*terraform.State) error {
+						for _, want := range []string{
+							"host1.example.com. 3600 IN A 10.5.0.1",
+							"1.0.5.10.in-addr.arpa. 3600 IN PTR host1.example.com.",
+							"host3.overflow.example.com. 60 IN A 10.5.1.1",
+							"1.1.5.10.in-addr.arpa. 60 IN PTR host3.overflow.example.com.",
+						} {
+							if !slices.Contains(server.Updates(), want) {
+								return fmt.Errorf("DNS update '%s' not received: %v", want, server.Updates())
+							}
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:      testAccIpamAllocateConfig_dnsUpdate(server.addr, 0),
+				ExpectError: regexp.MustCompile("'dns_ttl' of pool 'DNS_POOL2' must be at least 1"),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_dnsUpdate(server string, ttl int) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		dns_update = {
+			server        = "%s"
+			tsig_key_name = "test-key"
+			tsig_secret   = "%s"
+			on_failure    = "error"
+		}
+		pools = [
+			{
+				name           = "DNS_POOL1"
+				prefix_length  = 24
+				gateway        = "10.5.0.254"
+				dns_zone       = "example.com"
+				overflow_pools = ["DNS_POOL2"]
+				ranges = [
+					{
+						from_ip = "10.5.0.1"
+						to_ip   = "10.5.0.2"
+					}
+				]
+			},
+			{
+				name          = "DNS_POOL2"
+				prefix_length = 24
+				gateway       = "10.5.1.254"
+				dns_zone      = "overflow.example.com"
+				dns_ttl       = %d
+				ranges = [
+					{
+						from_ip = "10.5.1.1"
+						to_ip   = "10.5.1.10"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "DNS_POOL1"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+			"host3" = {}
+		}
+	}
+	`, server, testTsigSecret, ttl)
+}
+
+func TestAccIpamAllocateThresholds(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_thresholds(3, 90),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.#", "3"),
+				),
+			},
+			{
+				Config: testAccIpamAllocateConfig_thresholds(2, 40),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.#", "2"),
+				),
+			},
+			{
+				Config:      testAccIpamAllocateConfig_thresholds(4, 90),
+				ExpectError: regexp.MustCompile("Pool utilization exceeds error threshold"),
+			},
+			{
+				Config:      testAccIpamAllocateConfig_thresholds(5, 90),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Not enough IPs in pool"),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_thresholds(count, errorThreshold int) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		pools = [
+			{
+				name            = "THRESHOLD_POOL1"
+				prefix_length   = 24
+				gateway         = "10.6.0.254"
+				warn_threshold  = 50
+				error_threshold = %d
+				ranges = [
+					{
+						from_ip = "10.6.0.1"
+						to_ip   = "10.6.0.4"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "THRESHOLD_POOL1"
+		hosts = {
+			"host1" = {
+				count = %d
+			}
+		}
+	}
+	`, errorThreshold, count)
+}
+
+func TestAccIpamAllocateOverflowPools(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_overflowPools(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.7.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.pool", "PRIMARY_POOL1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ip", "10.7.0.2"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.pool", "PRIMARY_POOL1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ip", "10.7.1.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.prefix_length", "24"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.gateway", "10.7.1.254"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.pool", "OVERFLOW_POOL1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_overflowPools() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name           = "PRIMARY_POOL1"
+				prefix_length  = 24
+				gateway        = "10.7.0.254"
+				overflow_pools = ["OVERFLOW_POOL1"]
+				ranges = [
+					{
+						from_ip = "10.7.0.1"
+						to_ip   = "10.7.0.2"
+					}
+				]
+			},
+			{
+				name          = "OVERFLOW_POOL1"
+				prefix_length = 24
+				gateway       = "10.7.1.254"
+				ranges = [
+					{
+						from_ip = "10.7.1.1"
+						to_ip   = "10.7.1.10"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "PRIMARY_POOL1"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+			"host3" = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateOverflowPoolCollision(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccIpamAllocateConfig_overflowPoolCollision("OVERFLOW_POOL3", "[]"),
+				ExpectError: regexp.MustCompile("Pool 'OVERFLOW_POOL3' is an overflow pool of 'PRIMARY_POOL3'"),
+			},
+			{
+				Config:      testAccIpamAllocateConfig_overflowPoolCollision("PRIMARY_POOL3", `["OVERFLOW_POOL3"]`),
+				ExpectError: regexp.MustCompile("Overflow pool 'OVERFLOW_POOL3' of pool 'OTHER_POOL3' is already an overflow pool"),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_overflowPoolCollision(pool, otherOverflowPools string) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		pools = [
+			{
+				name           = "PRIMARY_POOL3"
+				prefix_length  = 24
+				gateway        = "10.7.4.254"
+				overflow_pools = ["OVERFLOW_POOL3"]
+				ranges = [
+					{
+						from_ip = "10.7.4.1"
+						to_ip   = "10.7.4.2"
+					}
+				]
+			},
+			{
+				name          = "OVERFLOW_POOL3"
+				prefix_length = 24
+				gateway       = "10.7.5.254"
+				ranges = [
+					{
+						from_ip = "10.7.5.1"
+						to_ip   = "10.7.5.10"
+					}
+				]
+			},
+			{
+				name           = "OTHER_POOL3"
+				prefix_length  = 24
+				gateway        = "10.7.6.254"
+				overflow_pools = %s
+				ranges = [
+					{
+						from_ip = "10.7.6.1"
+						to_ip   = "10.7.6.10"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "%s"
+		hosts = {
+			"host1" = {}
+		}
+	}
+	`, otherOverflowPools, pool)
+}
+
+func TestOverflowOwner(t *testing.T) {
+	r := &ipamAllocateResource{pools: []providerDataPool{
+		{Name: types.StringValue("POOL1"), OverflowPools: []types.String{types.StringValue("POOL2")}},
+		{Name: types.StringValue("POOL2")},
+	}}
+	for name, want := range map[string]string{"POOL1": "", "POOL2": "POOL1", "POOL3": ""} {
+		if got := r.overflowOwner(name); got != want {
+			t.Errorf("overflowOwner(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAccIpamAllocateOverflowThresholds(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_overflowThresholds(3),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ips.#", "3"),
+				),
+			},
+			{
+				Config:      testAccIpamAllocateConfig_overflowThresholds(4),
+				ExpectError: regexp.MustCompile("Pool 'OVERFLOW_POOL2' is 100% utilized"),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_overflowThresholds(count int) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		pools = [
+			{
+				name           = "PRIMARY_POOL2"
+				prefix_length  = 24
+				overflow_pools = ["OVERFLOW_POOL2"]
+				ranges = [
+					{
+						from_ip = "10.7.2.1"
+						to_ip   = "10.7.2.2"
+					}
+				]
+			},
+			{
+				name            = "OVERFLOW_POOL2"
+				prefix_length   = 24
+				error_threshold = 50
+				ranges = [
+					{
+						from_ip = "10.7.3.1"
+						to_ip   = "10.7.3.2"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "PRIMARY_POOL2"
+		hosts = {
+			"host1" = {
+				count = %d
+			}
+		}
+	}
+	`, count)
+}
+
+func TestAccIpamAllocateSelector(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_selector(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.8.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ip", "10.8.0.11"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ip", "10.8.0.100"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_selector() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name          = "LABEL_POOL1"
+				prefix_length = 24
+				gateway       = "10.8.0.254"
+				ranges = [
+					{
+						from_ip = "10.8.0.1"
+						to_ip   = "10.8.0.10"
+					},
+					{
+						from_ip = "10.8.0.11"
+						to_ip   = "10.8.0.20"
+						labels  = { rack = "r12" }
+					}
+				]
+				addresses = [
+					{
+						ip     = "10.8.0.100"
+						labels = { role = "oob" }
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "LABEL_POOL1"
+		hosts = {
+			"host1" = {}
+			"host2" = { selector = { rack = "r12" } }
+			"host3" = { selector = { role = "oob" } }
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateAffinityGroup(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_affinityGroup(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.spine1.ip", "10.9.0.1"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.spine2.ip", "10.9.1.1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_affinityGroup() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name          = "AFFINITY_POOL1"
+				prefix_length = 16
+				gateway       = "10.9.255.254"
+				ranges = [
+					{
+						from_ip = "10.9.0.1"
+						to_ip   = "10.9.0.10"
+					},
+					{
+						from_ip = "10.9.1.1"
+						to_ip   = "10.9.1.10"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "AFFINITY_POOL1"
+		hosts = {
+			"spine1" = { affinity_group = "spines" }
+			"spine2" = { affinity_group = "spines" }
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateHostOffset(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_hostOffset(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf-101.ip", "10.10.0.101"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf-102.ip", "10.10.0.102"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.border1.ip", "10.10.0.1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_hostOffset() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name          = "OFFSET_POOL1"
+				prefix_length = 24
+				gateway       = "10.10.0.254"
+				host_offset = {
+					pattern = "^leaf-(\\d+)$"
+					offset  = -1
+				}
+				ranges = [
+					{
+						from_ip = "10.10.0.1"
+						to_ip   = "10.10.0.200"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "OFFSET_POOL1"
+		hosts = {
+			"border1"  = {}
+			"leaf-101" = {}
+			"leaf-102" = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocateStep(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_step(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.11.0.4"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ip", "10.11.0.8"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_step() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name          = "STEP_POOL1"
+				prefix_length = 24
+				gateway       = "10.11.0.254"
+				ranges = [
+					{
+						from_ip = "10.11.0.1"
+						to_ip   = "10.11.0.100"
+						step    = 4
+						align   = 4
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "STEP_POOL1"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+		}
+	}
+	`
+}
+
+func TestAccIpamAllocatePriority(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_priority("last_free"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.12.0.100"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ip", "10.12.0.20"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ip", "10.12.0.19"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "strategy", "last_free"),
+				),
+			},
+			{
+				Config: testAccIpamAllocateConfig_priority("first_free"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host1.ip", "10.12.0.100"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host2.ip", "10.12.0.20"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.host3.ip", "10.12.0.19"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "strategy", "first_free"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_priority(strategy string) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		pools = [
+			{
+				name          = "PRIORITY_POOL1"
+				prefix_length = 24
+				gateway       = "10.12.0.254"
+				ranges = [
+					{
+						from_ip  = "10.12.0.1"
+						to_ip    = "10.12.0.10"
+						priority = -1
+					},
+					{
+						from_ip = "10.12.0.11"
+						to_ip   = "10.12.0.20"
+					}
+				]
+				addresses = [
+					{
+						ip       = "10.12.0.100"
+						priority = 1
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool     = "PRIORITY_POOL1"
+		strategy = "%s"
+		hosts = {
+			"host1" = {}
+			"host2" = {}
+			"host3" = {}
+		}
+	}
+	`, strategy)
+}
+
+func TestAccIpamAllocateHostOffsetPriority(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamAllocateConfig_hostOffsetPriority(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf-3.ip", "10.13.0.3"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.leaf-12.ip", "10.13.0.12"),
+					resource.TestCheckResourceAttr("ipam_allocate.test", "hosts.border1.ip", "10.13.0.11"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamAllocateConfig_hostOffsetPriority() string {
+	return `
+	provider "ipam" {
+		pools = [
+			{
+				name          = "OFFSET_POOL2"
+				prefix_length = 24
+				gateway       = "10.13.0.254"
+				host_offset = {
+					pattern = "^leaf-(\\d+)$"
+					offset  = -1
+				}
+				ranges = [
+					{
+						from_ip = "10.13.0.1"
+						to_ip   = "10.13.0.10"
+					},
+					{
+						from_ip  = "10.13.0.11"
+						to_ip    = "10.13.0.20"
+						priority = 10
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_allocate" "test" {
+		pool = "OFFSET_POOL2"
+		hosts = {
+			"border1" = {}
+			"leaf-3"  = {}
+			"leaf-12" = {}
+		}
+	}
+	`
+}
@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+var _ resource.Resource = (*ipamGroupAllocateResource)(nil)
+
+func NewIpamGroupAllocateResource() resource.Resource {
+	return &ipamGroupAllocateResource{}
+}
+
+type ipamGroupAllocateResource struct {
+	pools []providerDataPool
+}
+
+func (r *ipamGroupAllocateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_allocate"
+}
+
+func (r *ipamGroupAllocateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Allocate a virtual IP and one IP per member from a pool per unique group ID, e.g. for HSRP or VRRP. All addresses of a group are allocated from the same range and therefore share its prefix length and gateway. A single resource must be used per pool.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Random internal ID.",
+				Computed:    true,
+			},
+			"pool": schema.StringAttribute{
+				Description: "Pool name. Must reference a pool from the provider configuration.",
+				Required:    true,
+			},
+			"groups": schema.MapNestedAttribute{
+				Description: "A map of group IDs and its assigned addresses.",
+				Required:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"members": schema.MapNestedAttribute{
+							MarkdownDescription: "A map of member names and its assigned addresses.",
+							Required:            true,
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"ip": schema.StringAttribute{
+										MarkdownDescription: "IP address.",
+										Computed:            true,
+										PlanModifiers: []planmodifier.String{
+											stringplanmodifier.UseStateForUnknown(),
+										},
+									},
+								},
+							},
+						},
+						"vip": schema.StringAttribute{
+							MarkdownDescription: "Virtual IP address.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+						"prefix_length": schema.Int64Attribute{
+							MarkdownDescription: "Prefix length.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.Int64{
+								int64planmodifier.UseStateForUnknown(),
+							},
+						},
+						"gateway": schema.StringAttribute{
+							MarkdownDescription: "Gateway IP.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type GroupAllocate struct {
+	Id     types.String                  `tfsdk:"id"`
+	Pool   types.String                  `tfsdk:"pool"`
+	Groups map[string]GroupAllocateGroup `tfsdk:"groups"`
+}
+
+type GroupAllocateGroup struct {
+	Members      map[string]GroupAllocateMember `tfsdk:"members"`
+	Vip          types.String                   `tfsdk:"vip"`
+	PrefixLength types.Int64                    `tfsdk:"prefix_length"`
+	Gateway      types.String                   `tfsdk:"gateway"`
+}
+
+type GroupAllocateMember struct {
+	Ip types.String `tfsdk:"ip"`
+}
+
+func (r *ipamGroupAllocateResource) Configure(ctx context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.pools = req.ProviderData.(*providerData).Pools
+}
+
+func (r *ipamGroupAllocateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan, state GroupAllocate
+
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Create"))
+
+	diags = r.allocate(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Pool = plan.Pool
+	state.Groups = plan.Groups
+
+	rand.Seed(time.Now().UnixNano())
+	state.Id = types.StringValue(fmt.Sprint(rand.Int63()))
+
+	tflog.Debug(ctx, fmt.Sprintf("Create finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamGroupAllocateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state GroupAllocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamGroupAllocateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state GroupAllocate
+
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Update"))
+
+	diags = r.allocate(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Id = types.StringValue(plan.Id.ValueString())
+	state.Pool = plan.Pool
+	state.Groups = plan.Groups
+
+	tflog.Debug(ctx, fmt.Sprintf("Update finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamGroupAllocateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state GroupAllocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Delete"))
+
+	tflog.Debug(ctx, fmt.Sprintf("Delete finished successfully"))
+
+	resp.State.RemoveResource(ctx)
+}
+
+// allocate assigns a virtual IP and member IPs from a single range to every
+// group, keeping all existing addresses.
+func (r *ipamGroupAllocateResource) allocate(ctx context.Context, plan *GroupAllocate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var pool *providerDataPool
+
+	for i := range r.pools {
+		if r.pools[i].Name.ValueString() == plan.Pool.ValueString() {
+			pool = &r.pools[i]
+		}
+	}
+	if pool == nil {
+		diags.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", plan.Pool.ValueString()))
+		return diags
+	}
+
+	groups := make(map[string][]string, len(plan.Groups))
+	existing := make(map[string]ipam.Group)
+	for k, g := range plan.Groups {
+		groups[k] = make([]string, 0, len(g.Members))
+		group := ipam.Group{Members: make(map[string]ipam.Address)}
+		group.VIP.IP, _ = netip.ParseAddr(g.Vip.ValueString())
+		for m, member := range g.Members {
+			groups[k] = append(groups[k], m)
+			if ip, err := netip.ParseAddr(member.Ip.ValueString()); err == nil {
+				group.Members[m] = ipam.Address{IP: ip}
+			}
+		}
+		existing[k] = group
+	}
+
+	allocator := ipam.Allocator{Pool: ToIpamPool(pool)}
+	allocations, err := allocator.AllocateGroups(groups, existing)
+	if err != nil {
+		AddAllocationError(&diags, err)
+		return diags
+	}
+
+	for k, group := range allocations {
+		g := plan.Groups[k]
+		if group.VIP.IP != existing[k].VIP.IP {
+			g.Vip = addressIP(group.VIP)
+			g.PrefixLength = addressPrefixLength(group.VIP)
+			g.Gateway = addressGateway(group.VIP)
+			tflog.Debug(ctx, fmt.Sprintf("Allocate VIP to %s: %v", k, group.VIP.IP.String()))
+		}
+		for m, address := range group.Members {
+			if address.IP != existing[k].Members[m].IP {
+				g.Members[m] = GroupAllocateMember{Ip: addressIP(address)}
+				tflog.Debug(ctx, fmt.Sprintf("Allocate IP to %s member %s: %v", k, m, address.IP.String()))
+			}
+		}
+		plan.Groups[k] = g
+	}
+
+	return diags
+}
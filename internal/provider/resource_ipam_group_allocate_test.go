@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamGroupAllocate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamGroupAllocateConfig_initial(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.vip", "10.1.0.1"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.prefix_length", "24"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.gateway", "10.1.0.254"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.members.rtr1.ip", "10.1.0.2"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.members.rtr2.ip", "10.1.0.3"),
+				),
+			},
+			{
+				Config: providerConfig + testAccIpamGroupAllocateConfig_update1(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.vip", "10.1.0.1"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.members.rtr1.ip", "10.1.0.2"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.members.rtr2.ip", "10.1.0.3"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan10.members.rtr3.ip", "10.1.0.4"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan20.vip", "10.1.0.5"),
+					resource.TestCheckResourceAttr("ipam_group_allocate.test", "groups.vlan20.members.rtr1.ip", "10.1.0.6"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamGroupAllocateConfig_initial() string {
+	return `
+	resource "ipam_group_allocate" "test" {
+		pool = "POOL2"
+		groups = {
+			"vlan10" = {
+				members = {
+					"rtr1" = {}
+					"rtr2" = {}
+				}
+			}
+		}
+	}
+	`
+}
+
+func testAccIpamGroupAllocateConfig_update1() string {
+	return `
+	resource "ipam_group_allocate" "test" {
+		pool = "POOL2"
+		groups = {
+			"vlan10" = {
+				members = {
+					"rtr1" = {}
+					"rtr2" = {}
+					"rtr3" = {}
+				}
+			}
+			"vlan20" = {
+				members = {
+					"rtr1" = {}
+					"rtr2" = {}
+				}
+			}
+		}
+	}
+	`
+}
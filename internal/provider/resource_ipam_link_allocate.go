@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+var _ resource.Resource = (*ipamLinkAllocateResource)(nil)
+
+func NewIpamLinkAllocateResource() resource.Resource {
+	return &ipamLinkAllocateResource{}
+}
+
+type ipamLinkAllocateResource struct {
+	pools []providerDataPool
+}
+
+func (r *ipamLinkAllocateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_link_allocate"
+}
+
+func (r *ipamLinkAllocateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Allocate one point-to-point prefix from a pool per unique link ID and assign its two addresses to the link ends. Only aligned blocks whose addresses are all part of the pool are used. A single resource must be used per pool.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Random internal ID.",
+				Computed:    true,
+			},
+			"pool": schema.StringAttribute{
+				Description: "Pool name. Must reference a pool from the provider configuration.",
+				Required:    true,
+			},
+			"prefix_length": schema.Int64Attribute{
+				MarkdownDescription: "Link prefix length, e.g. `30`. Defaults to `31` for IPv4 and `127` for IPv6 pools. Changing it reallocates all links.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"links": schema.MapNestedAttribute{
+				Description: "A map of link IDs and its assigned addresses.",
+				Required:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"a_side": schema.StringAttribute{
+							MarkdownDescription: "Name of the A side of the link.",
+							Required:            true,
+						},
+						"b_side": schema.StringAttribute{
+							MarkdownDescription: "Name of the B side of the link.",
+							Required:            true,
+						},
+						"prefix": schema.StringAttribute{
+							MarkdownDescription: "Link prefix in CIDR notation.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+						"a_ip": schema.StringAttribute{
+							MarkdownDescription: "IP address of the A side, which is the first usable IP address of the prefix, e.g. `.1` of a `/30` or `::1` of a `/126`.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+						"b_ip": schema.StringAttribute{
+							MarkdownDescription: "IP address of the B side, which is the second usable IP address of the prefix, e.g. `.2` of a `/30` or `::2` of a `/126`.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type LinkAllocate struct {
+	Id           types.String                `tfsdk:"id"`
+	Pool         types.String                `tfsdk:"pool"`
+	PrefixLength types.Int64                 `tfsdk:"prefix_length"`
+	Links        map[string]LinkAllocateLink `tfsdk:"links"`
+}
+
+type LinkAllocateLink struct {
+	ASide  types.String `tfsdk:"a_side"`
+	BSide  types.String `tfsdk:"b_side"`
+	Prefix types.String `tfsdk:"prefix"`
+	AIp    types.String `tfsdk:"a_ip"`
+	BIp    types.String `tfsdk:"b_ip"`
+}
+
+func (r *ipamLinkAllocateResource) Configure(ctx context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.pools = req.ProviderData.(*providerData).Pools
+}
+
+func (r *ipamLinkAllocateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan, state LinkAllocate
+
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Create"))
+
+	diags = r.allocate(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Pool = plan.Pool
+	state.PrefixLength = plan.PrefixLength
+	state.Links = plan.Links
+
+	rand.Seed(time.Now().UnixNano())
+	state.Id = types.StringValue(fmt.Sprint(rand.Int63()))
+
+	tflog.Debug(ctx, fmt.Sprintf("Create finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamLinkAllocateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state LinkAllocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamLinkAllocateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state LinkAllocate
+
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Update"))
+
+	diags = r.allocate(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Id = types.StringValue(plan.Id.ValueString())
+	state.Pool = plan.Pool
+	state.PrefixLength = plan.PrefixLength
+	state.Links = plan.Links
+
+	tflog.Debug(ctx, fmt.Sprintf("Update finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamLinkAllocateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state LinkAllocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Delete"))
+
+	tflog.Debug(ctx, fmt.Sprintf("Delete finished successfully"))
+
+	resp.State.RemoveResource(ctx)
+}
+
+// allocate assigns a link prefix to every link without one, keeping all
+// existing link prefixes.
+func (r *ipamLinkAllocateResource) allocate(ctx context.Context, plan *LinkAllocate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var pool *providerDataPool
+
+	for i := range r.pools {
+		if r.pools[i].Name.ValueString() == plan.Pool.ValueString() {
+			pool = &r.pools[i]
+		}
+	}
+	if pool == nil {
+		diags.AddError("Pool not found", fmt.Sprintf("Pool '%s' not found.", plan.Pool.ValueString()))
+		return diags
+	}
+
+	ipamPool := ToIpamPool(pool)
+
+	bits := int(plan.PrefixLength.ValueInt64())
+	if plan.PrefixLength.IsNull() {
+		bits = 31
+		if addresses := ipamPool.Expand(); len(addresses) > 0 && addresses[0].IP.Is6() {
+			bits = 127
+		}
+	}
+
+	links := make([]string, 0, len(plan.Links))
+	existing := make(map[string]netip.Prefix)
+	for k, l := range plan.Links {
+		links = append(links, k)
+		if prefix, err := netip.ParsePrefix(l.Prefix.ValueString()); err == nil {
+			existing[k] = prefix
+		}
+	}
+
+	allocator := ipam.Allocator{Pool: ipamPool}
+	allocations, err := allocator.AllocateLinks(links, bits, existing)
+	if err != nil {
+		AddAllocationError(&diags, err)
+		return diags
+	}
+
+	for k, link := range allocations {
+		if link.Prefix == existing[k] {
+			continue
+		}
+		l := plan.Links[k]
+		plan.Links[k] = LinkAllocateLink{
+			ASide:  l.ASide,
+			BSide:  l.BSide,
+			Prefix: types.StringValue(link.Prefix.String()),
+			AIp:    types.StringValue(link.A.String()),
+			BIp:    types.StringValue(link.B.String()),
+		}
+		tflog.Debug(ctx, fmt.Sprintf("Allocate link prefix to %s: %v", k, link.Prefix.String()))
+	}
+
+	return diags
+}
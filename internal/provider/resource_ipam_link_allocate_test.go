@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamLinkAllocate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamLinkAllocateConfig_initial(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.prefix", "10.1.0.2/31"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.a_ip", "10.1.0.2"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.b_ip", "10.1.0.3"),
+				),
+			},
+			{
+				Config: providerConfig + testAccIpamLinkAllocateConfig_update1(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.prefix", "10.1.0.2/31"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link2.prefix", "10.1.0.4/31"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link2.a_ip", "10.1.0.4"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link2.b_ip", "10.1.0.5"),
+				),
+			},
+			{
+				Config: providerConfig + testAccIpamLinkAllocateConfig_update2(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.prefix", "10.1.0.4/30"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.a_ip", "10.1.0.5"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.b_ip", "10.1.0.6"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link2.prefix", "10.1.0.8/30"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamLinkAllocateConfig_initial() string {
+	return `
+	resource "ipam_link_allocate" "test" {
+		pool = "POOL2"
+		links = {
+			"link1" = { a_side = "spine1", b_side = "leaf1" }
+		}
+	}
+	`
+}
+
+func testAccIpamLinkAllocateConfig_update1() string {
+	return `
+	resource "ipam_link_allocate" "test" {
+		pool = "POOL2"
+		links = {
+			"link1" = { a_side = "spine1", b_side = "leaf1" }
+			"link2" = { a_side = "spine1", b_side = "leaf2" }
+		}
+	}
+	`
+}
+
+func testAccIpamLinkAllocateConfig_update2() string {
+	return `
+	resource "ipam_link_allocate" "test" {
+		pool          = "POOL2"
+		prefix_length = 30
+		links = {
+			"link1" = { a_side = "spine1", b_side = "leaf1" }
+			"link2" = { a_side = "spine1", b_side = "leaf2" }
+		}
+	}
+	`
+}
+
+func TestAccIpamLinkAllocateIpv6(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamLinkAllocateConfig_ipv6(127),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.prefix", "2001:db8::2/127"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.a_ip", "2001:db8::2"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.b_ip", "2001:db8::3"),
+				),
+			},
+			{
+				Config: testAccIpamLinkAllocateConfig_ipv6(126),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.prefix", "2001:db8::4/126"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.a_ip", "2001:db8::5"),
+					resource.TestCheckResourceAttr("ipam_link_allocate.test", "links.link1.b_ip", "2001:db8::6"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamLinkAllocateConfig_ipv6(prefixLength int) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		pools = [
+			{
+				name = "LINK_POOL6"
+				ranges = [
+					{
+						from_ip = "2001:db8::1"
+						to_ip   = "2001:db8::f"
+					}
+				]
+			}
+		]
+	}
+
+	resource "ipam_link_allocate" "test" {
+		pool          = "LINK_POOL6"
+		prefix_length = %d
+		links = {
+			"link1" = { a_side = "spine1", b_side = "leaf1" }
+		}
+	}
+	`, prefixLength)
+}
@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+var _ resource.Resource = (*ipamPrefixAllocateResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*ipamPrefixAllocateResource)(nil)
+
+func NewIpamPrefixAllocateResource() resource.Resource {
+	return &ipamPrefixAllocateResource{}
+}
+
+type ipamPrefixAllocateResource struct {
+	pools []providerDataPrefixPool
+}
+
+func (r *ipamPrefixAllocateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_prefix_allocate"
+}
+
+func (r *ipamPrefixAllocateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Allocate one child prefix of the requested length from a prefix pool per unique key. Larger prefixes are placed first and each prefix is placed in the smallest free block it fits in. Prefixes which are no longer part of the prefix pool are reallocated. A single resource must be used per prefix pool.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Random internal ID.",
+				Computed:    true,
+			},
+			"pool": schema.StringAttribute{
+				Description: "Prefix pool name. Must reference a prefix pool from the provider configuration.",
+				Required:    true,
+			},
+			"prefixes": schema.MapNestedAttribute{
+				Description: "A map of keys and its assigned prefixes.",
+				Required:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"prefix_length": schema.Int64Attribute{
+							MarkdownDescription: "Requested prefix length, e.g. `31` for a point-to-point link or `24` for a VLAN.",
+							Required:            true,
+						},
+						"prefix": schema.StringAttribute{
+							MarkdownDescription: "Allocated prefix in CIDR notation.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+						"first_ip": schema.StringAttribute{
+							MarkdownDescription: "First usable IP address.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+						"last_ip": schema.StringAttribute{
+							MarkdownDescription: "Last usable IP address.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+						"gateway": schema.StringAttribute{
+							MarkdownDescription: "Gateway IP, which is the first usable IP address.",
+							Computed:            true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type PrefixAllocate struct {
+	Id       types.String                    `tfsdk:"id"`
+	Pool     types.String                    `tfsdk:"pool"`
+	Prefixes map[string]PrefixAllocatePrefix `tfsdk:"prefixes"`
+}
+
+type PrefixAllocatePrefix struct {
+	PrefixLength types.Int64  `tfsdk:"prefix_length"`
+	Prefix       types.String `tfsdk:"prefix"`
+	FirstIp      types.String `tfsdk:"first_ip"`
+	LastIp       types.String `tfsdk:"last_ip"`
+	Gateway      types.String `tfsdk:"gateway"`
+}
+
+func (r *ipamPrefixAllocateResource) Configure(ctx context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.pools = req.ProviderData.(*providerData).PrefixPools
+}
+
+func (r *ipamPrefixAllocateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state PrefixAllocate
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var pool *ipam.PrefixPool
+	for i := range r.pools {
+		if r.pools[i].Name.Equal(plan.Pool) {
+			pool = ToIpamPrefixPool(&r.pools[i])
+		}
+	}
+
+	// a changed prefix length or a prefix which is no longer part of the pool
+	// requires a new prefix
+	for k, p := range plan.Prefixes {
+		s, ok := state.Prefixes[k]
+		if !ok {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s.Prefix.ValueString())
+		if p.PrefixLength.Equal(s.PrefixLength) && (pool == nil || err != nil || pool.Contains(prefix)) {
+			continue
+		}
+		for _, attr := range []string{"prefix", "first_ip", "last_ip", "gateway"} {
+			diags = resp.Plan.SetAttribute(ctx, path.Root("prefixes").AtMapKey(k).AtName(attr), types.StringUnknown())
+			resp.Diagnostics.Append(diags...)
+		}
+	}
+}
+
+func (r *ipamPrefixAllocateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan, state PrefixAllocate
+
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Create"))
+
+	diags = r.allocate(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Pool = plan.Pool
+	state.Prefixes = plan.Prefixes
+
+	rand.Seed(time.Now().UnixNano())
+	state.Id = types.StringValue(fmt.Sprint(rand.Int63()))
+
+	tflog.Debug(ctx, fmt.Sprintf("Create finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamPrefixAllocateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state PrefixAllocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Read"))
+
+	tflog.Debug(ctx, fmt.Sprintf("Read finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamPrefixAllocateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state PrefixAllocate
+
+	// Read plan
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Update"))
+
+	diags = r.allocate(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Id = types.StringValue(plan.Id.ValueString())
+	state.Pool = plan.Pool
+	state.Prefixes = plan.Prefixes
+
+	tflog.Debug(ctx, fmt.Sprintf("Update finished successfully"))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ipamPrefixAllocateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state PrefixAllocate
+
+	// Read state
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Beginning Delete"))
+
+	tflog.Debug(ctx, fmt.Sprintf("Delete finished successfully"))
+
+	resp.State.RemoveResource(ctx)
+}
+
+// allocate assigns a prefix to every entry without one, keeping all
+// existing prefixes which still match the requested prefix length.
+func (r *ipamPrefixAllocateResource) allocate(ctx context.Context, plan *PrefixAllocate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var pool *providerDataPrefixPool
+
+	for i := range r.pools {
+		if r.pools[i].Name.ValueString() == plan.Pool.ValueString() {
+			pool = &r.pools[i]
+		}
+	}
+	if pool == nil {
+		diags.AddError("Pool not found", fmt.Sprintf("Prefix pool '%s' not found.", plan.Pool.ValueString()))
+		return diags
+	}
+
+	requests := make(map[string]int, len(plan.Prefixes))
+	existing := make(map[string]netip.Prefix)
+	for k, p := range plan.Prefixes {
+		requests[k] = int(p.PrefixLength.ValueInt64())
+		if prefix, err := netip.ParsePrefix(p.Prefix.ValueString()); err == nil {
+			existing[k] = prefix
+		}
+	}
+
+	allocator := ipam.PrefixAllocator{Pool: ToIpamPrefixPool(pool)}
+	allocations, err := allocator.Allocate(requests, existing)
+	if err != nil {
+		AddAllocationError(&diags, err)
+		return diags
+	}
+
+	for k, prefix := range allocations {
+		if prefix == existing[k] {
+			continue
+		}
+		first, last := ipam.UsableRange(prefix)
+		plan.Prefixes[k] = PrefixAllocatePrefix{
+			PrefixLength: plan.Prefixes[k].PrefixLength,
+			Prefix:       types.StringValue(prefix.String()),
+			FirstIp:      types.StringValue(first.String()),
+			LastIp:       types.StringValue(last.String()),
+			Gateway:      types.StringValue(first.String()),
+		}
+		tflog.Debug(ctx, fmt.Sprintf("Allocate prefix to %s: %v", k, prefix.String()))
+	}
+
+	return diags
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIpamPrefixAllocate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccIpamPrefixAllocateConfig_initial(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.prefix", "10.0.0.0/25"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.first_ip", "10.0.0.1"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.last_ip", "10.0.0.126"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.gateway", "10.0.0.1"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.link1.prefix", "10.0.0.128/31"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.link1.first_ip", "10.0.0.128"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.link1.last_ip", "10.0.0.129"),
+				),
+			},
+			{
+				Config: providerConfig + testAccIpamPrefixAllocateConfig_update1(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.prefix", "10.0.0.0/25"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.link1.prefix", "10.0.0.128/31"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.link2.prefix", "10.0.0.130/31"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan20.prefix", "10.0.1.0/24"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamPrefixAllocateConfig_initial() string {
+	return `
+	resource "ipam_prefix_allocate" "test" {
+		pool = "PREFIX_POOL1"
+		prefixes = {
+			"vlan10" = { prefix_length = 25 }
+			"link1"  = { prefix_length = 31 }
+		}
+	}
+	`
+}
+
+func testAccIpamPrefixAllocateConfig_update1() string {
+	return `
+	resource "ipam_prefix_allocate" "test" {
+		pool = "PREFIX_POOL1"
+		prefixes = {
+			"vlan10" = { prefix_length = 25 }
+			"link1"  = { prefix_length = 31 }
+			"link2"  = { prefix_length = 31 }
+			"vlan20" = { prefix_length = 24 }
+		}
+	}
+	`
+}
+
+func TestAccIpamPrefixAllocateRemovedPrefix(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIpamPrefixAllocateConfig_removedPrefix("10.9.0.0/24"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.prefix", "10.9.0.0/25"),
+				),
+			},
+			{
+				Config: testAccIpamPrefixAllocateConfig_removedPrefix("10.9.1.0/24"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.prefix", "10.9.1.0/25"),
+					resource.TestCheckResourceAttr("ipam_prefix_allocate.test", "prefixes.vlan10.gateway", "10.9.1.1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIpamPrefixAllocateConfig_removedPrefix(parent string) string {
+	return fmt.Sprintf(`
+	provider "ipam" {
+		prefix_pools = [
+			{
+				name     = "PREFIX_POOL2"
+				prefixes = ["%s"]
+			}
+		]
+	}
+
+	resource "ipam_prefix_allocate" "test" {
+		pool = "PREFIX_POOL2"
+		prefixes = {
+			"vlan10" = { prefix_length = 25 }
+		}
+	}
+	`, parent)
+}
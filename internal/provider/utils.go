@@ -1,53 +1,123 @@
 package provider
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
 	"net/netip"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
 )
 
-func GetAddressesFromPool(pool *providerDataPool) []providerDataPoolAddress {
-	addresses := make([]providerDataPoolAddress, 0)
-	globalPrefixLength := pool.PrefixLength.ValueInt64()
-	globalGateway := pool.Gateway.ValueString()
+// ToIpamPool converts a pool from the provider configuration.
+func ToIpamPool(pool *providerDataPool) *ipam.Pool {
+	p := &ipam.Pool{
+		Name:         pool.Name.ValueString(),
+		PrefixLength: int(pool.PrefixLength.ValueInt64()),
+	}
+	p.Gateway, _ = netip.ParseAddr(pool.Gateway.ValueString())
 	for r := range pool.Ranges {
-		fromIp, _ := netip.ParseAddr(pool.Ranges[r].FromIP.ValueString())
-		toIp, _ := netip.ParseAddr(pool.Ranges[r].ToIP.ValueString())
-		var prefixLength int64
-		var gateway string
-		if pool.Ranges[r].PrefixLength.IsNull() {
-			prefixLength = globalPrefixLength
-		} else {
-			prefixLength = pool.Ranges[r].PrefixLength.ValueInt64()
-		}
-		if pool.Ranges[r].Gateway.IsNull() {
-			gateway = globalGateway
-		} else {
-			gateway = pool.Ranges[r].Gateway.ValueString()
-		}
-		ip := fromIp
-		for ip.Less(toIp) || ip == toIp {
-			addresses = append(addresses, providerDataPoolAddress{IP: types.StringValue(ip.String()), PrefixLength: types.Int64Value(prefixLength), Gateway: types.StringValue(gateway)})
-			ip = ip.Next()
-		}
+		var ipamRange ipam.Range
+		ipamRange.From, _ = netip.ParseAddr(pool.Ranges[r].FromIP.ValueString())
+		ipamRange.To, _ = netip.ParseAddr(pool.Ranges[r].ToIP.ValueString())
+		ipamRange.PrefixLength = int(pool.Ranges[r].PrefixLength.ValueInt64())
+		ipamRange.Gateway, _ = netip.ParseAddr(pool.Ranges[r].Gateway.ValueString())
+		ipamRange.Step = int(pool.Ranges[r].Step.ValueInt64())
+		ipamRange.Align = int(pool.Ranges[r].Align.ValueInt64())
+		ipamRange.Priority = int(pool.Ranges[r].Priority.ValueInt64())
+		ipamRange.Weight = int(pool.Ranges[r].Weight.ValueInt64())
+		p.Ranges = append(p.Ranges, ipamRange)
 	}
 	for a := range pool.Addresses {
-		var prefixLength int64
-		var gateway string
-		if pool.Addresses[a].PrefixLength.IsNull() {
-			prefixLength = globalPrefixLength
-		} else {
-			prefixLength = pool.Addresses[a].PrefixLength.ValueInt64()
-		}
-		if pool.Addresses[a].Gateway.IsNull() {
-			gateway = globalGateway
-		} else {
-			gateway = pool.Addresses[a].Gateway.ValueString()
+		var ipamAddress ipam.Address
+		ipamAddress.IP, _ = netip.ParseAddr(pool.Addresses[a].IP.ValueString())
+		ipamAddress.PrefixLength = int(pool.Addresses[a].PrefixLength.ValueInt64())
+		ipamAddress.Gateway, _ = netip.ParseAddr(pool.Addresses[a].Gateway.ValueString())
+		ipamAddress.Priority = int(pool.Addresses[a].Priority.ValueInt64())
+		ipamAddress.Weight = int(pool.Addresses[a].Weight.ValueInt64())
+		p.Addresses = append(p.Addresses, ipamAddress)
+	}
+	return p
+}
+
+// ToIpamPrefixPool converts a prefix pool from the provider configuration.
+func ToIpamPrefixPool(pool *providerDataPrefixPool) *ipam.PrefixPool {
+	p := &ipam.PrefixPool{
+		Name: pool.Name.ValueString(),
+	}
+	for _, prefix := range pool.Prefixes {
+		if parent, err := netip.ParsePrefix(prefix.ValueString()); err == nil {
+			p.Prefixes = append(p.Prefixes, parent)
 		}
-		ip := pool.Addresses[a].IP
-		addresses = append(addresses, providerDataPoolAddress{IP: ip, PrefixLength: types.Int64Value(prefixLength), Gateway: types.StringValue(gateway)})
 	}
-	return addresses
+	return p
+}
+
+// AddAllocationError adds an error returned by the ipam package.
+func AddAllocationError(diags *diag.Diagnostics, err error) {
+	switch {
+	case errors.Is(err, ipam.ErrExhausted):
+		diags.AddError("Not enough IPs in pool", err.Error())
+	case errors.Is(err, ipam.ErrInvalid):
+		diags.AddError("Invalid configuration", err.Error())
+	default:
+		diags.AddError("Allocation failed", err.Error())
+	}
+}
+
+// AddThresholdDiags adds a warning or an error if used of size addresses of a
+// pool exceed its 'warn_threshold' or 'error_threshold'. Nothing is reported
+// if the number of used addresses does not change. Exceeding the error
+// threshold is only an error if more addresses are used than previously, so
+// that plans releasing addresses of a full pool can still be applied.
+func AddThresholdDiags(diags *diag.Diagnostics, pool *providerDataPool, used, previous, size int) {
+	if size == 0 || used == previous {
+		return
+	}
+	remaining := max(size-used, 0)
+	detail := fmt.Sprintf("Pool '%s' is %d%% utilized, %d of %d addresses are allocated and %d remain.", pool.Name.ValueString(), used*100/size, used, size, remaining)
+	exceeds := func(threshold types.Int64) bool {
+		return !threshold.IsNull() && int64(used)*100 > threshold.ValueInt64()*int64(size)
+	}
+	switch {
+	case exceeds(pool.ErrorThreshold) && used > previous:
+		diags.AddError("Pool utilization exceeds error threshold", fmt.Sprintf("%s The error threshold is %d%%.", detail, pool.ErrorThreshold.ValueInt64()))
+	case exceeds(pool.ErrorThreshold):
+		diags.AddWarning("Pool utilization exceeds error threshold", fmt.Sprintf("%s The error threshold is %d%%.", detail, pool.ErrorThreshold.ValueInt64()))
+	case exceeds(pool.WarnThreshold):
+		diags.AddWarning("Pool utilization exceeds warning threshold", fmt.Sprintf("%s The warning threshold is %d%%.", detail, pool.WarnThreshold.ValueInt64()))
+	}
+}
+
+// maxSubnetBits limits subnets which are turned into a pool with all of their
+// usable addresses to 2^maxSubnetBits addresses, as every address of a pool is
+// expanded in memory.
+const maxSubnetBits = 16
+
+// subnetTooLarge returns true if a subnet has more than 2^maxSubnetBits
+// addresses, e.g. an IPv6 /64.
+func subnetTooLarge(prefix netip.Prefix) bool {
+	return prefix.Addr().BitLen()-prefix.Bits() > maxSubnetBits
+}
+
+func addressIP(address ipam.Address) types.String {
+	return types.StringValue(address.IP.String())
+}
+
+func addressPrefixLength(address ipam.Address) types.Int64 {
+	if address.PrefixLength == 0 {
+		return types.Int64Null()
+	}
+	return types.Int64Value(int64(address.PrefixLength))
+}
+
+func addressGateway(address ipam.Address) types.String {
+	if !address.Gateway.IsValid() {
+		return types.StringNull()
+	}
+	return types.StringValue(address.Gateway.String())
 }
 
 func ValidateIPAddress(ip string) bool {
@@ -65,10 +135,98 @@ func ValidatePrefixLength(prefixLength int64) bool {
 }
 
 func ValidateIPRange(fromIp, toIp string) bool {
-	f := netip.MustParseAddr(fromIp)
-	t := netip.MustParseAddr(toIp)
-	if !f.Less(t) {
+	f, err := netip.ParseAddr(fromIp)
+	if err != nil {
+		return true
+	}
+	t, err := netip.ParseAddr(toIp)
+	if err != nil {
+		return true
+	}
+	if f.Is4() != t.Is4() || !f.Less(t) {
+		return true
+	}
+	return false
+}
+
+func ValidatePrefix(prefix string) bool {
+	p, err := netip.ParsePrefix(prefix)
+	if err != nil || p != p.Masked() {
 		return true
 	}
 	return false
 }
+
+// addrToInt returns an IP address as integer. IPv4-mapped IPv6 addresses are
+// treated as IPv4 addresses.
+func addrToInt(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.Unmap().AsSlice())
+}
+
+// intToAddr returns the IP address of an integer with the given number of
+// bits and false if the integer is out of range.
+func intToAddr(n *big.Int, bitLen int) (netip.Addr, bool) {
+	if n.Sign() < 0 || n.BitLen() > bitLen {
+		return netip.Addr{}, false
+	}
+	addr, ok := netip.AddrFromSlice(n.FillBytes(make([]byte, bitLen/8)))
+	return addr, ok
+}
+
+// selectorMatch returns a function matching the addresses of all ranges and
+// addresses of the pools whose labels contain all labels of the selector, or
+// nil if the selector is empty.
+func selectorMatch(pools []*providerDataPool, selector map[string]string) func(ipam.Address) bool {
+	if len(selector) == 0 {
+		return nil
+	}
+	labelsMatch := func(labels map[string]types.String) bool {
+		for k, v := range selector {
+			if l, ok := labels[k]; !ok || l.ValueString() != v {
+				return false
+			}
+		}
+		return true
+	}
+	var ranges []ipam.Range
+	addresses := make(map[netip.Addr]bool)
+	for _, pool := range pools {
+		for _, r := range pool.Ranges {
+			if labelsMatch(r.Labels) {
+				var ipamRange ipam.Range
+				ipamRange.From, _ = netip.ParseAddr(r.FromIP.ValueString())
+				ipamRange.To, _ = netip.ParseAddr(r.ToIP.ValueString())
+				ranges = append(ranges, ipamRange)
+			}
+		}
+		for _, a := range pool.Addresses {
+			if ip, err := netip.ParseAddr(a.IP.ValueString()); err == nil && labelsMatch(a.Labels) {
+				addresses[ip] = true
+			}
+		}
+	}
+	return func(a ipam.Address) bool {
+		if addresses[a.IP] {
+			return true
+		}
+		for _, r := range ranges {
+			if r.From.BitLen() == a.IP.BitLen() && !a.IP.Less(r.From) && !r.To.Less(a.IP) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// addressPools returns the name of the first pool containing each address.
+func addressPools(pools []*ipam.Pool) map[netip.Addr]string {
+	names := make(map[netip.Addr]string)
+	for _, p := range pools {
+		for _, a := range p.Expand() {
+			if _, ok := names[a.IP]; !ok {
+				names[a.IP] = p.Name
+			}
+		}
+	}
+	return names
+}
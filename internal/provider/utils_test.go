@@ -0,0 +1,278 @@
+package provider
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+func TestValidateIPAddress(t *testing.T) {
+	tests := []struct {
+		ip      string
+		invalid bool
+	}{
+		{"10.0.0.1", false},
+		{"2001:db8::1", false},
+		{"::ffff:10.0.0.1", false},
+		{"", true},
+		{"10.0.0", true},
+		{"10.0.0.256", true},
+		{"10.0.0.1/24", true},
+		{"host1", true},
+	}
+	for _, tt := range tests {
+		if got := ValidateIPAddress(tt.ip); got != tt.invalid {
+			t.Errorf("ValidateIPAddress(%q) = %v, want %v", tt.ip, got, tt.invalid)
+		}
+	}
+}
+
+func TestValidatePrefixLength(t *testing.T) {
+	tests := []struct {
+		prefixLength int64
+		invalid      bool
+	}{
+		{-1, true},
+		{0, false},
+		{24, false},
+		{128, false},
+		{129, true},
+	}
+	for _, tt := range tests {
+		if got := ValidatePrefixLength(tt.prefixLength); got != tt.invalid {
+			t.Errorf("ValidatePrefixLength(%d) = %v, want %v", tt.prefixLength, got, tt.invalid)
+		}
+	}
+}
+
+func TestValidateIPRange(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		invalid bool
+	}{
+		{"10.0.0.1", "10.0.0.10", false},
+		{"10.0.0.255", "10.0.1.0", false},
+		{"2001:db8::1", "2001:db8::ffff", false},
+		{"10.0.0.1", "10.0.0.1", true},
+		{"10.0.0.10", "10.0.0.1", true},
+		{"10.0.0.1", "2001:db8::1", true},
+		{"invalid", "10.0.0.1", true},
+		{"10.0.0.1", "invalid", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		if got := ValidateIPRange(tt.from, tt.to); got != tt.invalid {
+			t.Errorf("ValidateIPRange(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.invalid)
+		}
+	}
+}
+
+func TestValidatePrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		invalid bool
+	}{
+		{"10.0.0.0/24", false},
+		{"0.0.0.0/0", false},
+		{"2001:db8::/32", false},
+		{"10.0.0.1/24", true},
+		{"10.0.0.0", true},
+		{"10.0.0.0/33", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := ValidatePrefix(tt.prefix); got != tt.invalid {
+			t.Errorf("ValidatePrefix(%q) = %v, want %v", tt.prefix, got, tt.invalid)
+		}
+	}
+}
+
+func TestToIpamPool(t *testing.T) {
+	pool := &providerDataPool{
+		Name:         types.StringValue("POOL1"),
+		PrefixLength: types.Int64Value(24),
+		Gateway:      types.StringValue("10.0.0.254"),
+		Ranges: []providerDataPoolRange{
+			{FromIP: types.StringValue("10.0.0.1"), ToIP: types.StringValue("10.0.0.5"), PrefixLength: types.Int64Null(), Gateway: types.StringNull()},
+			{FromIP: types.StringValue("10.0.1.1"), ToIP: types.StringValue("10.0.1.5"), PrefixLength: types.Int64Value(25), Gateway: types.StringValue("10.0.1.126")},
+		},
+		Addresses: []providerDataPoolAddress{
+			{IP: types.StringValue("10.0.2.1"), PrefixLength: types.Int64Null(), Gateway: types.StringNull()},
+		},
+	}
+	want := &ipam.Pool{
+		Name:         "POOL1",
+		PrefixLength: 24,
+		Gateway:      netip.MustParseAddr("10.0.0.254"),
+		Ranges: []ipam.Range{
+			{From: netip.MustParseAddr("10.0.0.1"), To: netip.MustParseAddr("10.0.0.5")},
+			{From: netip.MustParseAddr("10.0.1.1"), To: netip.MustParseAddr("10.0.1.5"), PrefixLength: 25, Gateway: netip.MustParseAddr("10.0.1.126")},
+		},
+		Addresses: []ipam.Address{
+			{IP: netip.MustParseAddr("10.0.2.1")},
+		},
+	}
+	if got := ToIpamPool(pool); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToIpamPool() = %+v, want %+v", got, want)
+	}
+}
+
+func TestToIpamPrefixPool(t *testing.T) {
+	pool := &providerDataPrefixPool{
+		Name:     types.StringValue("PREFIX_POOL1"),
+		Prefixes: []types.String{types.StringValue("10.0.0.0/24"), types.StringValue("2001:db8::/48")},
+	}
+	want := &ipam.PrefixPool{
+		Name:     "PREFIX_POOL1",
+		Prefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24"), netip.MustParsePrefix("2001:db8::/48")},
+	}
+	if got := ToIpamPrefixPool(pool); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToIpamPrefixPool() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAddAllocationError(t *testing.T) {
+	tests := []struct {
+		err     error
+		summary string
+	}{
+		{ipam.ErrExhausted, "Not enough IPs in pool"},
+		{ipam.ErrInvalid, "Invalid configuration"},
+		{errors.New("other"), "Allocation failed"},
+	}
+	for _, tt := range tests {
+		var diags diag.Diagnostics
+		AddAllocationError(&diags, tt.err)
+		if len(diags) != 1 || diags[0].Summary() != tt.summary {
+			t.Errorf("AddAllocationError(%v) = %v, want %q", tt.err, diags, tt.summary)
+		}
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	pool := &providerDataPool{
+		Ranges: []providerDataPoolRange{
+			{FromIP: types.StringValue("10.0.0.1"), ToIP: types.StringValue("10.0.0.5"), Labels: map[string]types.String{"rack": types.StringValue("r11")}},
+			{FromIP: types.StringValue("10.0.1.1"), ToIP: types.StringValue("10.0.1.5"), Labels: map[string]types.String{"rack": types.StringValue("r12"), "role": types.StringValue("server")}},
+		},
+		Addresses: []providerDataPoolAddress{
+			{IP: types.StringValue("10.0.2.1"), Labels: map[string]types.String{"role": types.StringValue("oob")}},
+		},
+	}
+	tests := []struct {
+		selector map[string]string
+		ip       string
+		want     bool
+	}{
+		{map[string]string{"rack": "r11"}, "10.0.0.3", true},
+		{map[string]string{"rack": "r11"}, "10.0.1.3", false},
+		{map[string]string{"rack": "r12", "role": "server"}, "10.0.1.5", true},
+		{map[string]string{"rack": "r12", "role": "oob"}, "10.0.1.5", false},
+		{map[string]string{"role": "oob"}, "10.0.2.1", true},
+		{map[string]string{"role": "oob"}, "10.0.2.2", false},
+	}
+	for _, tt := range tests {
+		match := selectorMatch([]*providerDataPool{pool}, tt.selector)
+		if got := match(ipam.Address{IP: netip.MustParseAddr(tt.ip)}); got != tt.want {
+			t.Errorf("selectorMatch(%v)(%s) = %v, want %v", tt.selector, tt.ip, got, tt.want)
+		}
+	}
+	if selectorMatch([]*providerDataPool{pool}, nil) != nil {
+		t.Errorf("selectorMatch(nil) != nil")
+	}
+}
+
+func TestAddressPools(t *testing.T) {
+	pools := []*ipam.Pool{
+		{Name: "POOL1", Ranges: []ipam.Range{{From: netip.MustParseAddr("10.0.0.1"), To: netip.MustParseAddr("10.0.0.2")}}},
+		{Name: "POOL2", Ranges: []ipam.Range{{From: netip.MustParseAddr("10.0.0.2"), To: netip.MustParseAddr("10.0.0.3")}}},
+	}
+	want := map[netip.Addr]string{
+		netip.MustParseAddr("10.0.0.1"): "POOL1",
+		netip.MustParseAddr("10.0.0.2"): "POOL1",
+		netip.MustParseAddr("10.0.0.3"): "POOL2",
+	}
+	if got := addressPools(pools); !reflect.DeepEqual(got, want) {
+		t.Errorf("addressPools() = %v, want %v", got, want)
+	}
+}
+
+func TestAddThresholdDiags(t *testing.T) {
+	pool := &providerDataPool{
+		Name:           types.StringValue("POOL1"),
+		WarnThreshold:  types.Int64Value(80),
+		ErrorThreshold: types.Int64Value(95),
+	}
+	tests := []struct {
+		used     int
+		previous int
+		severity diag.Severity
+		detail   string
+	}{
+		{80, 0, diag.Severity(0), ""},
+		{81, 0, diag.SeverityWarning, "Pool 'POOL1' is 81% utilized, 81 of 100 addresses are allocated and 19 remain. The warning threshold is 80%."},
+		{95, 0, diag.SeverityWarning, "Pool 'POOL1' is 95% utilized, 95 of 100 addresses are allocated and 5 remain. The warning threshold is 80%."},
+		{96, 0, diag.SeverityError, "Pool 'POOL1' is 96% utilized, 96 of 100 addresses are allocated and 4 remain. The error threshold is 95%."},
+		{101, 0, diag.SeverityError, "Pool 'POOL1' is 101% utilized, 101 of 100 addresses are allocated and 0 remain. The error threshold is 95%."},
+		{97, 97, diag.Severity(0), ""},
+		{97, 99, diag.SeverityWarning, "Pool 'POOL1' is 97% utilized, 97 of 100 addresses are allocated and 3 remain. The error threshold is 95%."},
+	}
+	for _, tt := range tests {
+		var diags diag.Diagnostics
+		AddThresholdDiags(&diags, pool, tt.used, tt.previous, 100)
+		if tt.detail == "" {
+			if len(diags) != 0 {
+				t.Errorf("AddThresholdDiags(%d, %d) = %v, want none", tt.used, tt.previous, diags)
+			}
+			continue
+		}
+		if len(diags) != 1 || diags[0].Severity() != tt.severity || diags[0].Detail() != tt.detail {
+			t.Errorf("AddThresholdDiags(%d, %d) = %v, want %v %q", tt.used, tt.previous, diags, tt.severity, tt.detail)
+		}
+	}
+}
+
+func FuzzValidateIPRange(f *testing.F) {
+	f.Add("10.0.0.1", "10.0.0.10")
+	f.Add("10.0.0.10", "10.0.0.1")
+	f.Add("2001:db8::1", "10.0.0.1")
+	f.Add("", "invalid")
+	f.Fuzz(func(t *testing.T, from, to string) {
+		invalid := ValidateIPRange(from, to)
+		if ValidateIPAddress(from) || ValidateIPAddress(to) {
+			if !invalid {
+				t.Fatalf("ValidateIPRange(%q, %q) accepted an invalid address", from, to)
+			}
+			return
+		}
+		fromIP, toIP := netip.MustParseAddr(from), netip.MustParseAddr(to)
+		if valid := fromIP.BitLen() == toIP.BitLen() && fromIP.Less(toIP); valid == invalid {
+			t.Fatalf("ValidateIPRange(%q, %q) = %v", from, to, invalid)
+		}
+	})
+}
+
+func FuzzValidatePrefix(f *testing.F) {
+	f.Add("10.0.0.0/24")
+	f.Add("10.0.0.1/24")
+	f.Add("2001:db8::/32")
+	f.Fuzz(func(t *testing.T, prefix string) {
+		if ValidatePrefix(prefix) {
+			return
+		}
+		p := netip.MustParsePrefix(prefix)
+		if p != p.Masked() {
+			t.Fatalf("ValidatePrefix(%q) accepted a prefix with host bits", prefix)
+		}
+		first, last := ipam.UsableRange(p)
+		if !p.Contains(first) || !p.Contains(last) || last.Less(first) {
+			t.Fatalf("UsableRange(%s) = %s, %s", p, first, last)
+		}
+	})
+}
@@ -0,0 +1,483 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+)
+
+// Request describes the addresses requested for a single host.
+type Request struct {
+	// Count is the number of addresses. A zero Count requests one address.
+	Count int
+	// Contiguous requests consecutive addresses. Blocks are searched in
+	// address order and ranked by the lowest Priority of their addresses.
+	Contiguous bool
+	// Preferred is the first address of a host without existing addresses.
+	// Preferred addresses are selected before all other addresses. If the
+	// address, or the block of a contiguous request starting at it, is not
+	// free, other addresses are selected unless Strict is set.
+	Preferred netip.Addr
+	Strict    bool
+	// AffinityGroup spreads the hosts of the group across failure domains.
+	// New addresses are selected from failure domains without addresses of
+	// other hosts of the group if possible.
+	AffinityGroup string
+	// Match restricts new addresses to the addresses of the pool for which
+	// it returns true. A nil Match accepts all addresses. Existing addresses
+	// are kept even if they no longer match.
+	Match func(Address) bool
+}
+
+func (r Request) count() int {
+	if r.Count == 0 {
+		return 1
+	}
+	return r.Count
+}
+
+// Allocator allocates addresses of a pool to hosts. A nil Strategy selects
+// the first free addresses. Reserved addresses are never selected. Addresses
+// of the Overflow pools are selected in order once all addresses of the
+// previous pools are in use.
+type Allocator struct {
+	Pool     *Pool
+	Strategy Strategy
+	Reserved []netip.Addr
+	Overflow []*Pool
+}
+
+// Allocate returns the addresses of every requested host. Existing addresses
+// of a host are kept, surplus addresses are released from the end and missing
+// addresses are selected from the free addresses of the pool. Hosts with
+// contiguous requests are allocated first, otherwise hosts are allocated in
+// lexical order. Existing addresses which are not part of the pool are
+// returned without prefix length and gateway.
+func (a *Allocator) Allocate(requests map[string]Request, existing map[string][]netip.Addr) (map[string][]Address, error) {
+	// addresses of all pools, tiers[t] is the index of the first address of
+	// pool t
+	var poolAddresses []Address
+	tiers := make([]int, 0, len(a.Overflow)+2)
+	for _, p := range append([]*Pool{a.Pool}, a.Overflow...) {
+		tiers = append(tiers, len(poolAddresses))
+		poolAddresses = append(poolAddresses, p.Expand()...)
+	}
+	tiers = append(tiers, len(poolAddresses))
+	poolIndex := make(map[netip.Addr]int, len(poolAddresses))
+	for pa := range poolAddresses {
+		if _, ok := poolIndex[poolAddresses[pa].IP]; !ok {
+			poolIndex[poolAddresses[pa].IP] = pa
+		}
+	}
+	// contiguous blocks are searched in the addresses of every pool in
+	// address order, as addresses ordered by priority are not consecutive
+	var ordered []Address
+	var orderedIndex map[netip.Addr]int
+	orderAddresses := func() {
+		if ordered != nil {
+			return
+		}
+		ordered = slices.Clone(poolAddresses)
+		for t := 0; t+1 < len(tiers); t++ {
+			addresses := ordered[tiers[t]:tiers[t+1]]
+			sort.SliceStable(addresses, func(i, j int) bool { return addresses[i].IP.Less(addresses[j].IP) })
+		}
+		orderedIndex = make(map[netip.Addr]int, len(ordered))
+		for pa := range ordered {
+			if _, ok := orderedIndex[ordered[pa].IP]; !ok {
+				orderedIndex[ordered[pa].IP] = pa
+			}
+		}
+	}
+
+	hosts := make([]string, 0, len(requests))
+	total := 0
+	for h, r := range requests {
+		if r.Count < 0 {
+			return nil, newError(ErrInvalid, fmt.Sprintf("'count' of '%s' must be at least 1.", h))
+		}
+		hosts = append(hosts, h)
+		total += r.count()
+	}
+	sort.Strings(hosts)
+
+	if total > len(poolAddresses) {
+		return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have enough IP addresses.", a.Pool.Name))
+	}
+
+	// get list of assigned addresses
+	allocations := make(map[string][]Address, len(requests))
+	inUse := make(map[netip.Addr]bool)
+	for _, h := range hosts {
+		ips := existing[h]
+		if len(ips) > requests[h].count() {
+			ips = ips[:requests[h].count()]
+		}
+		addresses := make([]Address, 0, requests[h].count())
+		for _, ip := range ips {
+			address := Address{IP: ip}
+			if pa, ok := poolIndex[ip]; ok {
+				address = poolAddresses[pa]
+			}
+			addresses = append(addresses, address)
+			inUse[ip] = true
+		}
+		if requests[h].Contiguous {
+			for i := 1; i < len(addresses); i++ {
+				if addresses[i-1].IP.Next() != addresses[i].IP {
+					return nil, newError(ErrExhausted, fmt.Sprintf("Addresses of '%s' are not consecutive.", h))
+				}
+			}
+		}
+		allocations[h] = addresses
+	}
+
+	reserved := 0
+	for _, ip := range a.Reserved {
+		if inUse[ip] {
+			continue
+		}
+		inUse[ip] = true
+		if _, ok := poolIndex[ip]; ok {
+			reserved++
+		}
+	}
+	if total+reserved > len(poolAddresses) {
+		return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have enough IP addresses.", a.Pool.Name))
+	}
+
+	// select preferred addresses in lexical order
+	for _, h := range hosts {
+		r := requests[h]
+		if !r.Preferred.IsValid() || len(allocations[h]) > 0 {
+			continue
+		}
+		var selected []Address
+		orderAddresses()
+		if pa, ok := orderedIndex[r.Preferred]; ok && (r.Match == nil || r.Match(ordered[pa])) {
+			n := 1
+			if r.Contiguous {
+				n = r.count()
+			}
+			selected = findContiguous(ordered[:tiers[tierOf(tiers, pa)+1]], inUse, r.Match, pa, n, true)
+		}
+		if selected == nil {
+			if r.Strict {
+				return nil, newError(ErrInvalid, fmt.Sprintf("Address '%s' of '%s' is not a free address of pool '%s'.", r.Preferred, h, a.Pool.Name))
+			}
+			continue
+		}
+		for _, s := range selected {
+			inUse[s.IP] = true
+		}
+		allocations[h] = selected
+	}
+
+	// allocate contiguous blocks first as they are harder to place, followed
+	// by restricted requests
+	sort.SliceStable(hosts, func(i, j int) bool {
+		ri, rj := requests[hosts[i]], requests[hosts[j]]
+		if ri.Contiguous != rj.Contiguous {
+			return ri.Contiguous
+		}
+		return ri.Match != nil && rj.Match == nil
+	})
+
+	strategy := a.Strategy
+	if strategy == nil {
+		strategy = FirstFree{}
+	}
+
+	// free addresses of every pool are collected once and shrunk after every
+	// selection
+	var free [][]Address
+	// selectFree returns the missing addresses of host h which match, or nil
+	// if there are not enough of them
+	selectFree := func(h string, addresses []Address, missing int, match func(Address) bool) ([]Address, error) {
+		var selected []Address
+		if requests[h].Contiguous {
+			// find next free block of consecutive IPs within a single pool
+			orderAddresses()
+			if len(addresses) > 0 {
+				last, ok := orderedIndex[addresses[len(addresses)-1].IP]
+				if !ok {
+					return nil, newError(ErrExhausted, fmt.Sprintf("Address '%s' of '%s' is no longer part of pool '%s'.", addresses[len(addresses)-1].IP, h, a.Pool.Name))
+				}
+				// the block must continue right after the last address
+				end := tiers[tierOf(tiers, last)+1]
+				if last+1 >= end || ordered[last].IP.Next() != ordered[last+1].IP {
+					return nil, nil
+				}
+				return findContiguous(ordered[:end], inUse, match, last+1, missing, true), nil
+			}
+			for t := 0; t+1 < len(tiers) && selected == nil; t++ {
+				selected = findContiguous(ordered[:tiers[t+1]], inUse, match, tiers[t], missing, false)
+			}
+			return selected, nil
+		}
+		// find next free IPs, starting with the first pool
+		if free == nil {
+			free = make([][]Address, len(tiers)-1)
+			for t := range free {
+				free[t] = make([]Address, 0, tiers[t+1]-tiers[t])
+				for pa := tiers[t]; pa < tiers[t+1]; pa++ {
+					if !inUse[poolAddresses[pa].IP] && poolIndex[poolAddresses[pa].IP] == pa {
+						free[t] = append(free[t], poolAddresses[pa])
+					}
+				}
+			}
+		}
+		for t := range free {
+			candidates := free[t]
+			if match != nil {
+				candidates = matching(free[t], match)
+			}
+			// addresses are ordered by priority, select from the highest
+			// priority first
+			for len(candidates) > 0 && len(selected) < missing {
+				n := 1
+				if candidates[len(candidates)-1].Priority == candidates[0].Priority {
+					n = len(candidates)
+				}
+				for n < len(candidates) && candidates[n].Priority == candidates[0].Priority {
+					n++
+				}
+				selected = append(selected, strategy.Select(candidates[:n], missing-len(selected))...)
+				candidates = candidates[n:]
+			}
+		}
+		if len(selected) < missing {
+			return nil, nil
+		}
+		return selected, nil
+	}
+
+	var domains map[netip.Addr]int
+	members := make(map[string][]string)
+	for _, h := range hosts {
+		if group := requests[h].AffinityGroup; group != "" {
+			members[group] = append(members[group], h)
+		}
+	}
+	for _, h := range hosts {
+		addresses := allocations[h]
+		missing := requests[h].count() - len(addresses)
+		if missing <= 0 {
+			continue
+		}
+		var selected []Address
+		var err error
+		if group := requests[h].AffinityGroup; group != "" {
+			// avoid the failure domains of the other hosts of the group
+			if domains == nil {
+				domains = a.domains()
+			}
+			avoid := make(map[int]bool)
+			for _, o := range members[group] {
+				if o == h {
+					continue
+				}
+				for _, address := range allocations[o] {
+					if d, ok := domains[address.IP]; ok {
+						avoid[d] = true
+					}
+				}
+			}
+			if len(avoid) > 0 {
+				match := requests[h].Match
+				spread := func(address Address) bool {
+					return !avoid[domains[address.IP]] && (match == nil || match(address))
+				}
+				selected, err = selectFree(h, addresses, missing, spread)
+			}
+		}
+		if selected == nil && err == nil {
+			selected, err = selectFree(h, addresses, missing, requests[h].Match)
+		}
+		switch {
+		case err != nil:
+			return nil, err
+		case selected == nil && requests[h].Contiguous:
+			return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have %d consecutive free IP addresses for '%s'.", a.Pool.Name, missing, h))
+		case selected == nil && requests[h].Match != nil:
+			return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have enough matching IP addresses for '%s'.", a.Pool.Name, h))
+		case selected == nil:
+			return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have enough IP addresses.", a.Pool.Name))
+		}
+		for _, s := range selected {
+			inUse[s.IP] = true
+		}
+		allocations[h] = append(addresses, selected...)
+		for t := range free {
+			free[t] = removeSelected(free[t], selected)
+		}
+	}
+
+	return allocations, nil
+}
+
+// AffinityConflict is a set of hosts of an affinity group which share a
+// failure domain.
+type AffinityConflict struct {
+	Group string
+	Hosts []string
+}
+
+// AffinityConflicts returns the hosts of every affinity group which share a
+// failure domain with another host of the group, ordered by group and host.
+// Failure domains are the address groups of the pool and its overflow pools
+// as returned by Groups, or every other address of the group if there is only
+// a single group.
+func (a *Allocator) AffinityConflicts(requests map[string]Request, allocations map[string][]Address) []AffinityConflict {
+	var domains map[netip.Addr]int
+	members := make(map[string]map[int][]string)
+	for h, r := range requests {
+		if r.AffinityGroup == "" {
+			continue
+		}
+		if domains == nil {
+			domains = a.domains()
+		}
+		if members[r.AffinityGroup] == nil {
+			members[r.AffinityGroup] = make(map[int][]string)
+		}
+		seen := make(map[int]bool)
+		for _, address := range allocations[h] {
+			if d, ok := domains[address.IP]; ok && !seen[d] {
+				seen[d] = true
+				members[r.AffinityGroup][d] = append(members[r.AffinityGroup][d], h)
+			}
+		}
+	}
+	conflicts := make([]AffinityConflict, 0)
+	for group, byDomain := range members {
+		shared := make(map[string]bool)
+		for _, hosts := range byDomain {
+			if len(hosts) > 1 {
+				for _, h := range hosts {
+					shared[h] = true
+				}
+			}
+		}
+		if len(shared) == 0 {
+			continue
+		}
+		c := AffinityConflict{Group: group}
+		for h := range shared {
+			c.Hosts = append(c.Hosts, h)
+		}
+		sort.Strings(c.Hosts)
+		conflicts = append(conflicts, c)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Group < conflicts[j].Group })
+	return conflicts
+}
+
+// domains returns the failure domain of every address of the pool and its
+// overflow pools.
+func (a *Allocator) domains() map[netip.Addr]int {
+	var groups [][]Address
+	for _, p := range append([]*Pool{a.Pool}, a.Overflow...) {
+		groups = append(groups, p.Groups()...)
+	}
+	domains := make(map[netip.Addr]int)
+	for g := range groups {
+		for i, address := range groups[g] {
+			if _, ok := domains[address.IP]; ok {
+				continue
+			}
+			if len(groups) == 1 {
+				// alternate between consecutive addresses of the range
+				domains[address.IP] = i % 2
+			} else {
+				domains[address.IP] = g
+			}
+		}
+	}
+	return domains
+}
+
+// tierOf returns the pool of the address at index pa.
+func tierOf(tiers []int, pa int) int {
+	t := 0
+	for t+2 < len(tiers) && tiers[t+1] <= pa {
+		t++
+	}
+	return t
+}
+
+// removeSelected removes the selected addresses from free in place, keeping
+// the order of the remaining addresses.
+func removeSelected(free []Address, selected []Address) []Address {
+	remove := make(map[netip.Addr]bool, len(selected))
+	for _, s := range selected {
+		remove[s.IP] = true
+	}
+	n := 0
+	for i, f := range free {
+		if len(remove) == 0 {
+			n += copy(free[n:], free[i:])
+			break
+		}
+		if remove[f.IP] {
+			delete(remove, f.IP)
+			continue
+		}
+		free[n] = f
+		n++
+	}
+	return free[:n]
+}
+
+// matching returns the addresses for which match returns true.
+func matching(addresses []Address, match func(Address) bool) []Address {
+	matched := make([]Address, 0, len(addresses))
+	for _, a := range addresses {
+		if match(a) {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
+
+// findContiguous returns n free consecutive addresses of the address ordered
+// poolAddresses starting at or after index start, which all match unless
+// match is nil. If fixed is set the block must begin exactly at start,
+// otherwise the first block with the highest priority of its lowest priority
+// address is returned.
+func findContiguous(poolAddresses []Address, inUse map[netip.Addr]bool, match func(Address) bool, start, n int, fixed bool) []Address {
+	if n <= 0 || start >= len(poolAddresses) {
+		return nil
+	}
+	highest := poolAddresses[start].Priority
+	for _, a := range poolAddresses[start:] {
+		highest = max(highest, a.Priority)
+	}
+	var best []Address
+	var bestPriority int
+	for s := start; s+n <= len(poolAddresses); s++ {
+		block := make([]Address, 0, n)
+		priority := poolAddresses[s].Priority
+		for pa := s; pa < s+n; pa++ {
+			if inUse[poolAddresses[pa].IP] || (match != nil && !match(poolAddresses[pa])) {
+				break
+			}
+			if pa > s && poolAddresses[pa-1].IP.Next() != poolAddresses[pa].IP {
+				break
+			}
+			block = append(block, poolAddresses[pa])
+			priority = min(priority, poolAddresses[pa].Priority)
+		}
+		if len(block) == n && (best == nil || priority > bestPriority) {
+			best, bestPriority = block, priority
+			if priority == highest {
+				return best
+			}
+		}
+		if fixed {
+			break
+		}
+	}
+	return best
+}
@@ -0,0 +1,538 @@
+package ipam
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	pool := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.8")}}}
+	tests := []struct {
+		name     string
+		requests map[string]Request
+		existing map[string][]netip.Addr
+		reserved []netip.Addr
+		want     map[string][]string
+		wantErr  error
+	}{
+		{
+			name:     "lexical order",
+			requests: map[string]Request{"b": {}, "a": {}, "c": {}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.0.2"}, "c": {"10.0.0.3"}},
+		},
+		{
+			name:     "keep existing",
+			requests: map[string]Request{"a": {}, "b": {}, "c": {}},
+			existing: map[string][]netip.Addr{"b": {addr("10.0.0.1")}, "c": {addr("10.0.0.5")}},
+			want:     map[string][]string{"a": {"10.0.0.2"}, "b": {"10.0.0.1"}, "c": {"10.0.0.5"}},
+		},
+		{
+			name:     "keep existing outside of pool",
+			requests: map[string]Request{"a": {}},
+			existing: map[string][]netip.Addr{"a": {addr("192.168.0.1")}},
+			want:     map[string][]string{"a": {"192.168.0.1"}},
+		},
+		{
+			name:     "count",
+			requests: map[string]Request{"a": {Count: 3}, "b": {}},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.2", "10.0.0.3"}, "b": {"10.0.0.4"}},
+		},
+		{
+			name:     "grow count",
+			requests: map[string]Request{"a": {Count: 3}, "b": {}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1")}, "b": {addr("10.0.0.2")}},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.3", "10.0.0.4"}, "b": {"10.0.0.2"}},
+		},
+		{
+			name:     "shrink count",
+			requests: map[string]Request{"a": {Count: 1}, "b": {}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1"), addr("10.0.0.2")}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.0.2"}},
+		},
+		{
+			name:     "contiguous",
+			requests: map[string]Request{"a": {}, "b": {Count: 3, Contiguous: true}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.3")}},
+			want:     map[string][]string{"a": {"10.0.0.3"}, "b": {"10.0.0.4", "10.0.0.5", "10.0.0.6"}},
+		},
+		{
+			name:     "grow contiguous",
+			requests: map[string]Request{"a": {Count: 2}, "b": {Count: 3, Contiguous: true}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1")}, "b": {addr("10.0.0.2")}},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.5"}, "b": {"10.0.0.2", "10.0.0.3", "10.0.0.4"}},
+		},
+		{
+			name:     "grow contiguous blocked",
+			requests: map[string]Request{"a": {}, "b": {Count: 2, Contiguous: true}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.2")}, "b": {addr("10.0.0.1")}},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "grow contiguous at end of pool",
+			requests: map[string]Request{"a": {Count: 3, Contiguous: true}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.7"), addr("10.0.0.8")}},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "contiguous with existing gap",
+			requests: map[string]Request{"a": {Count: 2, Contiguous: true}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1"), addr("10.0.0.3")}},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "reserved",
+			requests: map[string]Request{"a": {Count: 2}, "b": {Count: 2, Contiguous: true}},
+			reserved: []netip.Addr{addr("10.0.0.2"), addr("10.0.0.4"), addr("192.168.0.1")},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.3"}, "b": {"10.0.0.5", "10.0.0.6"}},
+		},
+		{
+			name:     "reserved existing",
+			requests: map[string]Request{"a": {}, "b": {}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1")}},
+			reserved: []netip.Addr{addr("10.0.0.1")},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.0.2"}},
+		},
+		{
+			name:     "exhausted",
+			requests: map[string]Request{"a": {Count: 8}, "b": {}},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "exhausted by reserved",
+			requests: map[string]Request{"a": {Count: 7}},
+			reserved: []netip.Addr{addr("10.0.0.7"), addr("10.0.0.8")},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "invalid count",
+			requests: map[string]Request{"a": {Count: -1}},
+			wantErr:  ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool, Reserved: tt.reserved}
+			got, err := a.Allocate(tt.requests, tt.existing)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			gotIps := make(map[string][]string, len(got))
+			for h, addresses := range got {
+				gotIps[h] = ips(addresses)
+			}
+			if !reflect.DeepEqual(gotIps, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", gotIps, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateSettings(t *testing.T) {
+	a := Allocator{Pool: testPool()}
+	got, err := a.Allocate(map[string]Request{"a": {Count: 4}}, nil)
+	if err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+	want := []Address{
+		{IP: addr("1.1.1.1"), PrefixLength: 22, Gateway: addr("1.1.1.201")},
+		{IP: addr("1.1.1.2"), PrefixLength: 22, Gateway: addr("1.1.1.201")},
+		{IP: addr("1.1.1.10"), PrefixLength: 23, Gateway: addr("1.1.1.200")},
+		{IP: addr("1.1.1.11"), PrefixLength: 24, Gateway: addr("1.1.1.254")},
+	}
+	if !reflect.DeepEqual(got["a"], want) {
+		t.Errorf("Allocate() = %v, want %v", got["a"], want)
+	}
+}
+
+func TestAllocateStrategy(t *testing.T) {
+	a := Allocator{Pool: testPool(), Strategy: LastFree{}}
+	got, err := a.Allocate(map[string]Request{"a": {}, "b": {}}, map[string][]netip.Addr{"a": {addr("1.1.1.12")}})
+	if err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+	if ip := got["a"][0].IP.String(); ip != "1.1.1.12" {
+		t.Errorf("Allocate() a = %s, want 1.1.1.12", ip)
+	}
+	if ip := got["b"][0].IP.String(); ip != "1.1.1.11" {
+		t.Errorf("Allocate() b = %s, want 1.1.1.11", ip)
+	}
+}
+
+func TestAllocateOverflow(t *testing.T) {
+	pool := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.3")}}}
+	overflow := []*Pool{
+		{Name: "OVERFLOW1", PrefixLength: 24, Gateway: addr("10.0.1.254"), Ranges: []Range{{From: addr("10.0.1.1"), To: addr("10.0.1.3")}}},
+		{Name: "OVERFLOW2", PrefixLength: 24, Gateway: addr("10.0.2.254"), Ranges: []Range{{From: addr("10.0.2.1"), To: addr("10.0.2.4")}}},
+	}
+	tests := []struct {
+		name     string
+		strategy Strategy
+		requests map[string]Request
+		existing map[string][]netip.Addr
+		want     map[string][]string
+		wantErr  error
+	}{
+		{
+			name:     "primary first",
+			requests: map[string]Request{"a": {}, "b": {}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.0.2"}},
+		},
+		{
+			name:     "overflow in order",
+			requests: map[string]Request{"a": {Count: 2}, "b": {Count: 2}, "c": {Count: 3}},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.2"}, "b": {"10.0.0.3", "10.0.1.1"}, "c": {"10.0.1.2", "10.0.1.3", "10.0.2.1"}},
+		},
+		{
+			name:     "last free within pools",
+			strategy: LastFree{},
+			requests: map[string]Request{"a": {Count: 2}, "b": {Count: 2}},
+			want:     map[string][]string{"a": {"10.0.0.3", "10.0.0.2"}, "b": {"10.0.0.1", "10.0.1.3"}},
+		},
+		{
+			name:     "keep existing overflow",
+			requests: map[string]Request{"a": {}, "b": {}},
+			existing: map[string][]netip.Addr{"b": {addr("10.0.2.1")}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.2.1"}},
+		},
+		{
+			name:     "contiguous within single pool",
+			requests: map[string]Request{"a": {}, "b": {Count: 4, Contiguous: true}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.2.1", "10.0.2.2", "10.0.2.3", "10.0.2.4"}},
+		},
+		{
+			name:     "grow contiguous within overflow pool",
+			requests: map[string]Request{"a": {Count: 2, Contiguous: true}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.1.3")}},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "exhausted",
+			requests: map[string]Request{"a": {Count: 10}, "b": {}},
+			wantErr:  ErrExhausted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool, Strategy: tt.strategy, Overflow: overflow}
+			got, err := a.Allocate(tt.requests, tt.existing)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			gotIps := make(map[string][]string, len(got))
+			for h, addresses := range got {
+				gotIps[h] = ips(addresses)
+			}
+			if !reflect.DeepEqual(gotIps, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", gotIps, tt.want)
+			}
+		})
+	}
+	got, err := (&Allocator{Pool: pool, Overflow: overflow}).Allocate(map[string]Request{"a": {Count: 4}}, nil)
+	if err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+	if want := (Address{IP: addr("10.0.1.1"), PrefixLength: 24, Gateway: addr("10.0.1.254")}); got["a"][3] != want {
+		t.Errorf("Allocate() = %v, want overflow address %v", got["a"][3], want)
+	}
+}
+
+func TestAllocateMatch(t *testing.T) {
+	pool := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.8")}}}
+	upper := func(a Address) bool { return addr("10.0.0.5").Compare(a.IP) <= 0 }
+	tests := []struct {
+		name     string
+		requests map[string]Request
+		existing map[string][]netip.Addr
+		want     map[string][]string
+		wantErr  error
+	}{
+		{
+			name:     "match",
+			requests: map[string]Request{"a": {}, "b": {Count: 2, Match: upper}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.0.5", "10.0.0.6"}},
+		},
+		{
+			name:     "restricted before unrestricted",
+			requests: map[string]Request{"a": {Count: 6}, "b": {Count: 2, Match: upper}},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.7", "10.0.0.8"}, "b": {"10.0.0.5", "10.0.0.6"}},
+		},
+		{
+			name:     "contiguous match",
+			requests: map[string]Request{"a": {Count: 3, Contiguous: true, Match: upper}},
+			want:     map[string][]string{"a": {"10.0.0.5", "10.0.0.6", "10.0.0.7"}},
+		},
+		{
+			name:     "keep existing not matching",
+			requests: map[string]Request{"a": {Count: 2, Match: upper}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1")}},
+			want:     map[string][]string{"a": {"10.0.0.1", "10.0.0.5"}},
+		},
+		{
+			name:     "exhausted",
+			requests: map[string]Request{"a": {Count: 5, Match: upper}},
+			wantErr:  ErrExhausted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool}
+			got, err := a.Allocate(tt.requests, tt.existing)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			gotIps := make(map[string][]string, len(got))
+			for h, addresses := range got {
+				gotIps[h] = ips(addresses)
+			}
+			if !reflect.DeepEqual(gotIps, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", gotIps, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateAffinityGroup(t *testing.T) {
+	twoRanges := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.4")}, {From: addr("10.0.1.1"), To: addr("10.0.1.4")}}}
+	oneRange := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.8")}}}
+	steppedRange := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.2"), To: addr("10.0.0.16"), Step: 2}}}
+	tests := []struct {
+		name          string
+		pool          *Pool
+		requests      map[string]Request
+		existing      map[string][]netip.Addr
+		want          map[string][]string
+		wantConflicts []AffinityConflict
+	}{
+		{
+			name:          "spread across ranges",
+			pool:          twoRanges,
+			requests:      map[string]Request{"leaf1": {}, "spine1": {AffinityGroup: "spines"}, "spine2": {AffinityGroup: "spines"}},
+			want:          map[string][]string{"leaf1": {"10.0.0.1"}, "spine1": {"10.0.0.2"}, "spine2": {"10.0.1.1"}},
+			wantConflicts: []AffinityConflict{},
+		},
+		{
+			name:          "spread from existing",
+			pool:          twoRanges,
+			requests:      map[string]Request{"spine1": {AffinityGroup: "spines"}, "spine2": {AffinityGroup: "spines"}},
+			existing:      map[string][]netip.Addr{"spine2": {addr("10.0.1.3")}},
+			want:          map[string][]string{"spine1": {"10.0.0.1"}, "spine2": {"10.0.1.3"}},
+			wantConflicts: []AffinityConflict{},
+		},
+		{
+			name:          "alternate addresses of a single range",
+			pool:          oneRange,
+			requests:      map[string]Request{"fw-a": {AffinityGroup: "fw"}, "fw-b": {AffinityGroup: "fw"}},
+			existing:      map[string][]netip.Addr{"fw-a": {addr("10.0.0.3")}},
+			want:          map[string][]string{"fw-a": {"10.0.0.3"}, "fw-b": {"10.0.0.2"}},
+			wantConflicts: []AffinityConflict{},
+		},
+		{
+			name:          "alternate addresses of a stepped range",
+			pool:          steppedRange,
+			requests:      map[string]Request{"fw-a": {AffinityGroup: "fw"}, "fw-b": {AffinityGroup: "fw"}},
+			existing:      map[string][]netip.Addr{"fw-a": {addr("10.0.0.4")}},
+			want:          map[string][]string{"fw-a": {"10.0.0.4"}, "fw-b": {"10.0.0.2"}},
+			wantConflicts: []AffinityConflict{},
+		},
+		{
+			name:          "not enough failure domains",
+			pool:          twoRanges,
+			requests:      map[string]Request{"spine1": {AffinityGroup: "spines"}, "spine2": {AffinityGroup: "spines"}, "spine3": {AffinityGroup: "spines"}},
+			want:          map[string][]string{"spine1": {"10.0.0.1"}, "spine2": {"10.0.1.1"}, "spine3": {"10.0.0.2"}},
+			wantConflicts: []AffinityConflict{{Group: "spines", Hosts: []string{"spine1", "spine3"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: tt.pool}
+			got, err := a.Allocate(tt.requests, tt.existing)
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			gotIps := make(map[string][]string, len(got))
+			for h, addresses := range got {
+				gotIps[h] = ips(addresses)
+			}
+			if !reflect.DeepEqual(gotIps, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", gotIps, tt.want)
+			}
+			if conflicts := a.AffinityConflicts(tt.requests, got); !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("AffinityConflicts() = %v, want %v", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestAllocatePreferred(t *testing.T) {
+	pool := &Pool{Name: "POOL", PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.8")}}}
+	tests := []struct {
+		name     string
+		requests map[string]Request
+		existing map[string][]netip.Addr
+		reserved []netip.Addr
+		want     map[string][]string
+		wantErr  error
+	}{
+		{
+			name:     "preferred before others",
+			requests: map[string]Request{"a": {}, "leaf-1": {Preferred: addr("10.0.0.1")}},
+			want:     map[string][]string{"a": {"10.0.0.2"}, "leaf-1": {"10.0.0.1"}},
+		},
+		{
+			name:     "preferred count",
+			requests: map[string]Request{"a": {Count: 2, Preferred: addr("10.0.0.4")}},
+			want:     map[string][]string{"a": {"10.0.0.4", "10.0.0.1"}},
+		},
+		{
+			name:     "preferred contiguous",
+			requests: map[string]Request{"a": {Count: 2, Contiguous: true, Preferred: addr("10.0.0.4")}},
+			want:     map[string][]string{"a": {"10.0.0.4", "10.0.0.5"}},
+		},
+		{
+			name:     "keep existing",
+			requests: map[string]Request{"a": {Preferred: addr("10.0.0.4")}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.2")}},
+			want:     map[string][]string{"a": {"10.0.0.2"}},
+		},
+		{
+			name:     "conflict with existing",
+			requests: map[string]Request{"a": {}, "b": {Preferred: addr("10.0.0.1")}},
+			existing: map[string][]netip.Addr{"a": {addr("10.0.0.1")}},
+			want:     map[string][]string{"a": {"10.0.0.1"}, "b": {"10.0.0.2"}},
+		},
+		{
+			name:     "conflict with reserved",
+			requests: map[string]Request{"a": {Preferred: addr("10.0.0.1")}},
+			reserved: []netip.Addr{addr("10.0.0.1")},
+			want:     map[string][]string{"a": {"10.0.0.2"}},
+		},
+		{
+			name:     "conflict between hosts",
+			requests: map[string]Request{"a": {Preferred: addr("10.0.0.3")}, "b": {Preferred: addr("10.0.0.3")}},
+			want:     map[string][]string{"a": {"10.0.0.3"}, "b": {"10.0.0.1"}},
+		},
+		{
+			name:     "outside of pool",
+			requests: map[string]Request{"a": {Preferred: addr("10.0.1.1")}},
+			want:     map[string][]string{"a": {"10.0.0.1"}},
+		},
+		{
+			name:     "strict conflict",
+			requests: map[string]Request{"a": {Preferred: addr("10.0.0.3")}, "b": {Preferred: addr("10.0.0.3"), Strict: true}},
+			wantErr:  ErrInvalid,
+		},
+		{
+			name:     "strict outside of pool",
+			requests: map[string]Request{"a": {Preferred: addr("10.0.1.1"), Strict: true}},
+			wantErr:  ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool, Reserved: tt.reserved}
+			got, err := a.Allocate(tt.requests, tt.existing)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			gotIps := make(map[string][]string, len(got))
+			for h, addresses := range got {
+				gotIps[h] = ips(addresses)
+			}
+			if !reflect.DeepEqual(gotIps, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", gotIps, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocatePriority(t *testing.T) {
+	pool := &Pool{
+		Name:         "POOL",
+		PrefixLength: 24,
+		Gateway:      addr("10.0.0.254"),
+		Ranges:       []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.4")}, {From: addr("10.0.1.1"), To: addr("10.0.1.2"), Priority: 1}},
+	}
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []string
+	}{
+		{"first free", FirstFree{}, []string{"10.0.1.1", "10.0.1.2", "10.0.0.1"}},
+		{"last free", LastFree{}, []string{"10.0.1.2", "10.0.1.1", "10.0.0.4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool, Strategy: tt.strategy}
+			got, err := a.Allocate(map[string]Request{"a": {Count: 3}}, nil)
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if ips := ips(got["a"]); !reflect.DeepEqual(ips, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", ips, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateContiguousPriority(t *testing.T) {
+	pool := &Pool{
+		Name:         "POOL",
+		PrefixLength: 24,
+		Gateway:      addr("10.0.0.254"),
+		Ranges:       []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.4")}, {From: addr("10.0.0.5"), To: addr("10.0.0.8"), Priority: 10}},
+	}
+	tests := []struct {
+		name  string
+		count int
+		want  []string
+	}{
+		{"block across priorities", 6, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}},
+		{"block of highest priority", 3, []string{"10.0.0.5", "10.0.0.6", "10.0.0.7"}},
+		{"block of all addresses", 8, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7", "10.0.0.8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool}
+			got, err := a.Allocate(map[string]Request{"a": {Count: tt.count, Contiguous: true}}, nil)
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if ips := ips(got["a"]); !reflect.DeepEqual(ips, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", ips, tt.want)
+			}
+		})
+	}
+
+	// existing blocks continue in address order
+	a := Allocator{Pool: pool}
+	got, err := a.Allocate(map[string]Request{"a": {Count: 3, Contiguous: true}}, map[string][]netip.Addr{"a": {addr("10.0.0.3"), addr("10.0.0.4")}})
+	if want := []string{"10.0.0.3", "10.0.0.4", "10.0.0.5"}; err != nil || !reflect.DeepEqual(ips(got["a"]), want) {
+		t.Errorf("Allocate() = %v, %v, want %v", ips(got["a"]), err, want)
+	}
+}
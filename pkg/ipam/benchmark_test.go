@@ -0,0 +1,97 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+func largePool() *Pool {
+	return &Pool{
+		Name:         "LARGE",
+		PrefixLength: 16,
+		Gateway:      addr("10.0.255.254"),
+		Ranges:       []Range{{From: addr("10.0.0.1"), To: addr("10.0.255.253")}},
+	}
+}
+
+func BenchmarkExpand(b *testing.B) {
+	pool := largePool()
+	for i := 0; i < b.N; i++ {
+		pool.Expand()
+	}
+}
+
+func BenchmarkAllocate(b *testing.B) {
+	a := Allocator{Pool: largePool()}
+	requests := make(map[string]Request)
+	for h := 0; h < 1000; h++ {
+		requests[fmt.Sprintf("host%d", h)] = Request{}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Allocate(requests, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAllocateExisting(b *testing.B) {
+	a := Allocator{Pool: largePool()}
+	requests := make(map[string]Request)
+	existing := make(map[string][]netip.Addr)
+	ip := addr("10.0.0.1")
+	for h := 0; h < 50000; h++ {
+		requests[fmt.Sprintf("host%d", h)] = Request{}
+		existing[fmt.Sprintf("host%d", h)] = []netip.Addr{ip}
+		ip = ip.Next()
+	}
+	for h := 50000; h < 50010; h++ {
+		requests[fmt.Sprintf("host%d", h)] = Request{}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Allocate(requests, existing); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAllocateContiguous(b *testing.B) {
+	a := Allocator{Pool: largePool()}
+	requests := make(map[string]Request)
+	for h := 0; h < 1000; h++ {
+		requests[fmt.Sprintf("host%d", h)] = Request{Count: 4, Contiguous: true}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Allocate(requests, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrefixAllocate(b *testing.B) {
+	a := PrefixAllocator{Pool: &PrefixPool{Name: "LARGE", Prefixes: []netip.Prefix{prefix("10.0.0.0/16")}}}
+	requests := make(map[string]int)
+	for k := 0; k < 256; k++ {
+		requests[fmt.Sprintf("net%d", k)] = 24 + k%8
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Allocate(requests, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSummarize(b *testing.B) {
+	prefixes := make([]netip.Prefix, 0, 4096)
+	for i := 0; i < 4096; i++ {
+		prefixes = append(prefixes, netip.PrefixFrom(netip.AddrFrom4([4]byte{10, byte(i >> 8), byte(i), 1}), 32))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Summarize(prefixes, 1<<20)
+	}
+}
@@ -0,0 +1,35 @@
+package ipam_test
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/netascode/terraform-provider-ipam/pkg/ipam"
+)
+
+func ExampleAllocator_Allocate() {
+	pool := &ipam.Pool{
+		Name:         "POOL1",
+		PrefixLength: 24,
+		Gateway:      netip.MustParseAddr("10.0.0.254"),
+		Ranges: []ipam.Range{
+			{From: netip.MustParseAddr("10.0.0.1"), To: netip.MustParseAddr("10.0.0.10")},
+		},
+	}
+	allocator := ipam.Allocator{Pool: pool}
+	existing := map[string][]netip.Addr{"host2": {netip.MustParseAddr("10.0.0.1")}}
+	allocations, err := allocator.Allocate(map[string]ipam.Request{"host1": {}, "host2": {}, "host3": {Count: 2}}, existing)
+	if err != nil {
+		panic(err)
+	}
+	for _, host := range []string{"host1", "host2", "host3"} {
+		for _, a := range allocations[host] {
+			fmt.Printf("%s %s/%d via %s\n", host, a.IP, a.PrefixLength, a.Gateway)
+		}
+	}
+	// Output:
+	// host1 10.0.0.2/24 via 10.0.0.254
+	// host2 10.0.0.1/24 via 10.0.0.254
+	// host3 10.0.0.3/24 via 10.0.0.254
+	// host3 10.0.0.4/24 via 10.0.0.254
+}
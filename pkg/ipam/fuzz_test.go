@@ -0,0 +1,166 @@
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+// fuzzRequests derives up to 16 hosts with up to 4 addresses each from data.
+func fuzzRequests(data []byte) map[string]Request {
+	requests := make(map[string]Request)
+	for _, b := range data {
+		requests[fmt.Sprintf("host%d", b>>4)] = Request{Count: int(b&3) + 1, Contiguous: b&4 != 0}
+	}
+	return requests
+}
+
+func checkAllocations(t *testing.T, pool *Pool, requests map[string]Request, existing, allocations map[string][]netip.Addr) {
+	inPool := make(map[netip.Addr]bool)
+	for _, a := range pool.Expand() {
+		inPool[a.IP] = true
+	}
+	seen := make(map[netip.Addr]string)
+	for h, r := range requests {
+		ips := allocations[h]
+		if len(ips) != r.count() {
+			t.Fatalf("%s has %d addresses, want %d", h, len(ips), r.count())
+		}
+		for i, ip := range ips {
+			if other, ok := seen[ip]; ok {
+				t.Fatalf("%s allocated to %s and %s", ip, other, h)
+			}
+			seen[ip] = h
+			if !inPool[ip] {
+				t.Fatalf("%s of %s is not part of the pool", ip, h)
+			}
+			if i < len(existing[h]) && existing[h][i] != ip {
+				t.Fatalf("%s of %s changed to %s", existing[h][i], h, ip)
+			}
+		}
+		if r.Contiguous {
+			for i := 1; i < len(ips); i++ {
+				if ips[i-1].Next() != ips[i] {
+					t.Fatalf("%v of %s are not consecutive", ips, h)
+				}
+			}
+		}
+	}
+	if len(allocations) != len(requests) {
+		t.Fatalf("%d hosts allocated, want %d", len(allocations), len(requests))
+	}
+}
+
+func FuzzAllocate(f *testing.F) {
+	f.Add(uint8(10), []byte{0x00, 0x11, 0x26}, []byte{0x03, 0x11, 0x36})
+	f.Add(uint8(3), []byte{0x07, 0x10}, []byte{0x07, 0x13})
+	f.Add(uint8(60), []byte{0x14, 0x25, 0x36, 0x47}, []byte{0x47})
+	f.Fuzz(func(t *testing.T, size uint8, first, second []byte) {
+		pool := &Pool{
+			Name:         "FUZZ",
+			PrefixLength: 24,
+			Gateway:      addr("10.0.0.254"),
+			Ranges:       []Range{{From: addr("10.0.0.1"), To: netip.AddrFrom4([4]byte{10, 0, 0, size%64 + 2})}},
+			Addresses:    []Address{{IP: addr("10.0.1.1")}, {IP: addr("10.0.1.2")}},
+		}
+		a := Allocator{Pool: pool}
+
+		previous := make(map[string][]netip.Addr)
+		for _, data := range [][]byte{first, second} {
+			requests := fuzzRequests(data)
+			allocations, err := a.Allocate(requests, previous)
+			if errors.Is(err, ErrExhausted) {
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			current := make(map[string][]netip.Addr, len(allocations))
+			for h, addresses := range allocations {
+				for _, address := range addresses {
+					current[h] = append(current[h], address.IP)
+				}
+			}
+			checkAllocations(t, pool, requests, previous, current)
+			previous = current
+		}
+	})
+}
+
+func FuzzPrefixAllocate(f *testing.F) {
+	f.Add([]byte{24, 25, 31, 30}, []byte{26, 31})
+	f.Add([]byte{23}, []byte{32, 32, 32})
+	f.Fuzz(func(t *testing.T, first, second []byte) {
+		pool := &PrefixPool{Name: "FUZZ", Prefixes: []netip.Prefix{prefix("10.0.0.0/24"), prefix("10.0.1.0/25")}}
+		a := PrefixAllocator{Pool: pool}
+
+		previous := make(map[string]netip.Prefix)
+		for _, data := range [][]byte{first, second} {
+			requests := make(map[string]int)
+			for i, b := range data {
+				requests[fmt.Sprintf("net%d", i%8)] = int(b % 33)
+			}
+			allocations, err := a.Allocate(requests, previous)
+			if errors.Is(err, ErrExhausted) {
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			for k, p := range allocations {
+				if p.Bits() != requests[k] {
+					t.Fatalf("%s of %s has length %d, want %d", p, k, p.Bits(), requests[k])
+				}
+				if !overlapsAny(p, pool.Prefixes) || p != p.Masked() {
+					t.Fatalf("%s of %s is not part of the pool", p, k)
+				}
+				if old, ok := previous[k]; ok && old.Bits() == p.Bits() && old != p {
+					t.Fatalf("%s of %s changed to %s", old, k, p)
+				}
+				for k2, p2 := range allocations {
+					if k != k2 && p.Overlaps(p2) {
+						t.Fatalf("%s of %s overlaps %s of %s", p, k, p2, k2)
+					}
+				}
+			}
+			previous = allocations
+		}
+	})
+}
+
+func FuzzSummarize(f *testing.F) {
+	f.Add(uint8(0), []byte{0, 1, 2, 3, 4})
+	f.Add(uint8(4), []byte{1, 2, 16, 18})
+	f.Add(uint8(100), []byte{7, 200, 201, 33, 34, 35})
+	f.Fuzz(func(t *testing.T, maxOvercoverage uint8, data []byte) {
+		inputs := make(map[netip.Addr]bool)
+		prefixes := make([]netip.Prefix, 0, len(data))
+		for _, b := range data {
+			ip := netip.AddrFrom4([4]byte{10, 0, 0, b})
+			inputs[ip] = true
+			prefixes = append(prefixes, netip.PrefixFrom(ip, 32))
+		}
+		summary := Summarize(prefixes, uint64(maxOvercoverage))
+
+		covered := 0
+		for i, p := range summary {
+			if i > 0 && !LastAddr(summary[i-1]).Less(p.Addr()) {
+				t.Fatalf("%s and %s overlap or are not sorted", summary[i-1], p)
+			}
+			covered += 1 << (32 - p.Bits())
+		}
+		for ip := range inputs {
+			found := false
+			for _, p := range summary {
+				found = found || p.Contains(ip)
+			}
+			if !found {
+				t.Fatalf("%s is not covered by %v", ip, summary)
+			}
+		}
+		if extra := covered - len(inputs); extra > int(maxOvercoverage) {
+			t.Fatalf("%v covers %d additional addresses, want at most %d", summary, extra, maxOvercoverage)
+		}
+	})
+}
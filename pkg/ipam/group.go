@@ -0,0 +1,127 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// Group is a virtual IP and one address per member, e.g. of a first-hop
+// redundancy group. All addresses of a group share the prefix length and
+// gateway of the virtual IP.
+type Group struct {
+	VIP     Address
+	Members map[string]Address
+}
+
+// AllocateGroups returns a group for every requested group ID with the
+// requested members. All addresses of a group are allocated from the same
+// range. Existing addresses are kept and new members are allocated from the
+// range of the existing virtual IP. Existing addresses which are not part of
+// the pool are returned without prefix length and gateway.
+func (a *Allocator) AllocateGroups(groups map[string][]string, existing map[string]Group) (map[string]Group, error) {
+	poolGroups := a.Pool.Groups()
+	poolGroupIndex := make(map[netip.Addr]int)
+	poolAddresses := make(map[netip.Addr]Address)
+	for g := range poolGroups {
+		for _, pa := range poolGroups[g] {
+			if _, ok := poolGroupIndex[pa.IP]; !ok {
+				poolGroupIndex[pa.IP] = g
+				poolAddresses[pa.IP] = pa
+			}
+		}
+	}
+	resolve := func(ip netip.Addr) Address {
+		if pa, ok := poolAddresses[ip]; ok {
+			return pa
+		}
+		return Address{IP: ip}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// get list of assigned addresses
+	allocations := make(map[string]Group, len(groups))
+	inUse := make(map[netip.Addr]bool)
+	for _, k := range keys {
+		group := Group{Members: make(map[string]Address)}
+		if e, ok := existing[k]; ok && e.VIP.IP.IsValid() {
+			group.VIP = resolve(e.VIP.IP)
+			inUse[e.VIP.IP] = true
+			for _, m := range groups[k] {
+				if address, ok := e.Members[m]; ok && address.IP.IsValid() {
+					group.Members[m] = resolve(address.IP)
+					inUse[address.IP] = true
+				}
+			}
+		}
+		allocations[k] = group
+	}
+
+	for _, k := range keys {
+		group := allocations[k]
+
+		members := append([]string(nil), groups[k]...)
+		sort.Strings(members)
+
+		missing := len(members) - len(group.Members)
+		if group.VIP.IP.IsValid() && missing == 0 {
+			continue
+		}
+
+		g, ok := poolGroupIndex[group.VIP.IP]
+		if !ok && group.VIP.IP.IsValid() {
+			return nil, newError(ErrExhausted, fmt.Sprintf("VIP '%s' of '%s' is no longer part of pool '%s'.", group.VIP.IP, k, a.Pool.Name))
+		}
+		if !ok {
+			// find a range with enough free IPs for a new group
+			g = -1
+			for pg := range poolGroups {
+				free := 0
+				for _, pa := range poolGroups[pg] {
+					if !inUse[pa.IP] {
+						free += 1
+					}
+				}
+				if free >= len(members)+1 {
+					g = pg
+					break
+				}
+			}
+			if g < 0 {
+				return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have a range with %d free IP addresses for '%s'.", a.Pool.Name, len(members)+1, k))
+			}
+		}
+
+		for _, pa := range poolGroups[g] {
+			if inUse[pa.IP] {
+				continue
+			}
+			if !group.VIP.IP.IsValid() {
+				group.VIP = pa
+				inUse[pa.IP] = true
+				continue
+			}
+			for _, m := range members {
+				if _, ok := group.Members[m]; !ok {
+					group.Members[m] = pa
+					inUse[pa.IP] = true
+					break
+				}
+			}
+		}
+
+		for _, m := range members {
+			if _, ok := group.Members[m]; !ok {
+				return nil, newError(ErrExhausted, fmt.Sprintf("Range of '%s' in pool '%s' does not have enough IP addresses for member '%s'.", k, a.Pool.Name, m))
+			}
+		}
+		allocations[k] = group
+	}
+
+	return allocations, nil
+}
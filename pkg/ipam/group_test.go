@@ -0,0 +1,62 @@
+package ipam
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAllocateGroups(t *testing.T) {
+	pool := &Pool{
+		Name:         "POOL",
+		PrefixLength: 24,
+		Gateway:      addr("10.0.0.254"),
+		Ranges: []Range{
+			{From: addr("10.0.0.1"), To: addr("10.0.0.3")},
+			{From: addr("10.0.1.1"), To: addr("10.0.1.9"), Gateway: addr("10.0.1.254")},
+		},
+	}
+	a := Allocator{Pool: pool}
+
+	got, err := a.AllocateGroups(map[string][]string{"g1": {"r1", "r2"}, "g2": {"r2", "r1"}}, nil)
+	if err != nil {
+		t.Fatalf("AllocateGroups() error = %v", err)
+	}
+	want := map[string]Group{
+		"g1": {
+			VIP:     Address{IP: addr("10.0.0.1"), PrefixLength: 24, Gateway: addr("10.0.0.254")},
+			Members: map[string]Address{"r1": {IP: addr("10.0.0.2"), PrefixLength: 24, Gateway: addr("10.0.0.254")}, "r2": {IP: addr("10.0.0.3"), PrefixLength: 24, Gateway: addr("10.0.0.254")}},
+		},
+		"g2": {
+			VIP:     Address{IP: addr("10.0.1.1"), PrefixLength: 24, Gateway: addr("10.0.1.254")},
+			Members: map[string]Address{"r1": {IP: addr("10.0.1.2"), PrefixLength: 24, Gateway: addr("10.0.1.254")}, "r2": {IP: addr("10.0.1.3"), PrefixLength: 24, Gateway: addr("10.0.1.254")}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AllocateGroups() = %v, want %v", got, want)
+	}
+
+	// new members are allocated from the range of the VIP
+	got, err = a.AllocateGroups(map[string][]string{"g1": {"r1"}, "g2": {"r1", "r2", "r3"}}, got)
+	if err != nil {
+		t.Fatalf("AllocateGroups() error = %v", err)
+	}
+	if ip := got["g2"].Members["r3"].IP.String(); ip != "10.0.1.4" {
+		t.Errorf("AllocateGroups() g2 r3 = %s, want 10.0.1.4", ip)
+	}
+	if _, ok := got["g1"].Members["r2"]; ok {
+		t.Errorf("AllocateGroups() g1 r2 not released")
+	}
+
+	// the range of the VIP has no free addresses
+	_, err = a.AllocateGroups(map[string][]string{"g1": {"r1", "r2", "r3"}}, want)
+	if !errors.Is(err, ErrExhausted) {
+		t.Errorf("AllocateGroups() error = %v, want %v", err, ErrExhausted)
+	}
+
+	// no range has enough free addresses
+	_, err = a.AllocateGroups(map[string][]string{"g1": {"r1", "r2", "r3", "r4", "r5", "r6", "r7", "r8", "r9"}}, nil)
+	if !errors.Is(err, ErrExhausted) {
+		t.Errorf("AllocateGroups() error = %v, want %v", err, ErrExhausted)
+	}
+}
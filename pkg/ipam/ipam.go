@@ -0,0 +1,41 @@
+// Package ipam implements the pool expansion and allocation logic of the IPAM
+// Terraform provider. It only depends on the standard library so that other
+// tools can make exactly the same allocation decisions as the provider.
+//
+// A Pool consists of IP ranges and standalone addresses, which are expanded by
+// priority and in declaration order. An Allocator assigns addresses of a Pool
+// to hosts, always keeping the addresses a host already has and picking new
+// addresses with a Strategy. PrefixPool and PrefixAllocator do the same for
+// child prefixes.
+package ipam
+
+import (
+	"errors"
+)
+
+var (
+	// ErrExhausted is returned when a pool does not have enough free
+	// addresses or prefixes for a request.
+	ErrExhausted = errors.New("not enough IPs in pool")
+	// ErrInvalid is returned for invalid pools or requests.
+	ErrInvalid = errors.New("invalid request")
+)
+
+// allocError is an error with a human readable message that matches one of
+// the sentinel errors.
+type allocError struct {
+	kind error
+	msg  string
+}
+
+func (e *allocError) Error() string {
+	return e.msg
+}
+
+func (e *allocError) Unwrap() error {
+	return e.kind
+}
+
+func newError(kind error, msg string) error {
+	return &allocError{kind: kind, msg: msg}
+}
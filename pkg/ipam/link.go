@@ -0,0 +1,71 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// Link is a point-to-point prefix and the addresses of both link ends.
+type Link struct {
+	Prefix netip.Prefix
+	A      netip.Addr
+	B      netip.Addr
+}
+
+// NewLink returns the link of a point-to-point prefix. The link ends are the
+// first two usable addresses of the prefix for both IPv4 and IPv6, e.g. '.1'
+// and '.2' of a /30 and '::1' and '::2' of a /126.
+func NewLink(prefix netip.Prefix) Link {
+	a, last := UsableRange(prefix)
+	b := a.Next()
+	if last.Less(b) {
+		b = last
+	}
+	return Link{Prefix: prefix, A: a, B: b}
+}
+
+// AllocateLinks returns a link prefix of the given length for every link.
+// Existing link prefixes are kept, new links get the next free aligned block
+// of the pool in lexical order of the link IDs.
+func (a *Allocator) AllocateLinks(links []string, bits int, existing map[string]netip.Prefix) (map[string]Link, error) {
+	if bits < 1 || bits > 127 {
+		return nil, newError(ErrInvalid, "'prefix_length' must be a number between 1 and 127.")
+	}
+
+	candidates := a.Pool.LinkPrefixes(bits)
+
+	keys := append([]string(nil), links...)
+	sort.Strings(keys)
+
+	// keep existing link prefixes
+	allocations := make(map[string]Link, len(keys))
+	var used []netip.Prefix
+	var pending []string
+	for _, k := range keys {
+		if prefix, ok := existing[k]; ok && prefix.IsValid() && !overlapsAny(prefix, used) {
+			allocations[k] = NewLink(prefix)
+			used = append(used, prefix)
+			continue
+		}
+		pending = append(pending, k)
+	}
+
+	for _, k := range pending {
+		// find next free link prefix
+		var prefix netip.Prefix
+		for _, c := range candidates {
+			if !overlapsAny(c, used) {
+				prefix = c
+				break
+			}
+		}
+		if !prefix.IsValid() {
+			return nil, newError(ErrExhausted, fmt.Sprintf("Pool '%s' does not have enough free /%d prefixes.", a.Pool.Name, bits))
+		}
+		allocations[k] = NewLink(prefix)
+		used = append(used, prefix)
+	}
+
+	return allocations, nil
+}
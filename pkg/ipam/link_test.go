@@ -0,0 +1,89 @@
+package ipam
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestNewLink(t *testing.T) {
+	tests := []struct {
+		prefix string
+		wantA  string
+		wantB  string
+	}{
+		{"10.0.0.4/30", "10.0.0.5", "10.0.0.6"},
+		{"10.0.0.4/31", "10.0.0.4", "10.0.0.5"},
+		{"10.0.0.8/29", "10.0.0.9", "10.0.0.10"},
+		{"2001:db8::4/126", "2001:db8::5", "2001:db8::6"},
+		{"2001:db8::4/127", "2001:db8::4", "2001:db8::5"},
+	}
+	for _, tt := range tests {
+		if got := NewLink(prefix(tt.prefix)); got.A.String() != tt.wantA || got.B.String() != tt.wantB {
+			t.Errorf("NewLink(%s) = %s, %s, want %s, %s", tt.prefix, got.A, got.B, tt.wantA, tt.wantB)
+		}
+	}
+}
+
+func TestAllocateLinks(t *testing.T) {
+	pool := &Pool{Name: "POOL", PrefixLength: 24, Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.15")}}}
+	tests := []struct {
+		name     string
+		links    []string
+		bits     int
+		existing map[string]netip.Prefix
+		want     map[string]Link
+		wantErr  error
+	}{
+		{
+			name:  "p2p",
+			links: []string{"b", "a"},
+			bits:  31,
+			want: map[string]Link{
+				"a": {Prefix: prefix("10.0.0.2/31"), A: addr("10.0.0.2"), B: addr("10.0.0.3")},
+				"b": {Prefix: prefix("10.0.0.4/31"), A: addr("10.0.0.4"), B: addr("10.0.0.5")},
+			},
+		},
+		{
+			name:     "keep existing",
+			links:    []string{"a", "b"},
+			bits:     30,
+			existing: map[string]netip.Prefix{"b": prefix("10.0.0.4/30")},
+			want: map[string]Link{
+				"a": {Prefix: prefix("10.0.0.8/30"), A: addr("10.0.0.9"), B: addr("10.0.0.10")},
+				"b": {Prefix: prefix("10.0.0.4/30"), A: addr("10.0.0.5"), B: addr("10.0.0.6")},
+			},
+		},
+		{
+			name:    "exhausted",
+			links:   []string{"a", "b", "c", "d"},
+			bits:    30,
+			wantErr: ErrExhausted,
+		},
+		{
+			name:    "invalid",
+			links:   []string{"a"},
+			bits:    128,
+			wantErr: ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Allocator{Pool: pool}
+			got, err := a.AllocateLinks(tt.links, tt.bits, tt.existing)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("AllocateLinks() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AllocateLinks() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllocateLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package ipam
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"net/netip"
+	"sort"
+)
+
+// Address is an IP address together with the prefix length and gateway of
+// its subnet. Priority and Weight are used for selection, see Range.
+type Address struct {
+	IP           netip.Addr
+	PrefixLength int
+	Gateway      netip.Addr
+	Priority     int
+	Weight       int
+}
+
+// Range is a range of consecutive IP addresses. A zero PrefixLength or an
+// invalid Gateway inherits the value of the pool. A Step greater than 1 only
+// includes every Step-th address and an Align greater than 1, which must be a
+// power of two, moves the first address to the next multiple of Align, e.g.
+// Step 4 and Align 4 include 10.0.0.4, 10.0.0.8 and so on of 10.0.0.1 to
+// 10.0.0.254. Addresses with a higher Priority are selected first, and
+// the Random strategy selects addresses proportionally to their Weight, where
+// a Weight of zero counts as 1.
+type Range struct {
+	From         netip.Addr
+	To           netip.Addr
+	PrefixLength int
+	Gateway      netip.Addr
+	Step         int
+	Align        int
+	Priority     int
+	Weight       int
+}
+
+// Exclude returns the parts of the range which are not covered by any of the
+// excluded ranges, in ascending order. The returned ranges keep the prefix
+// length and gateway of r and may consist of a single address.
+func (r Range) Exclude(excluded ...Range) []Range {
+	ranges := []Range{r}
+	for _, e := range excluded {
+		remaining := make([]Range, 0, len(ranges)+1)
+		for _, c := range ranges {
+			if e.From.BitLen() != c.From.BitLen() || e.To.Less(c.From) || c.To.Less(e.From) {
+				remaining = append(remaining, c)
+				continue
+			}
+			if c.From.Less(e.From) {
+				lower := c
+				lower.To = e.From.Prev()
+				remaining = append(remaining, lower)
+			}
+			if e.To.Less(c.To) {
+				upper := c
+				upper.From = e.To.Next()
+				remaining = append(remaining, upper)
+			}
+		}
+		ranges = remaining
+	}
+	return ranges
+}
+
+// Pool is a named set of IP ranges and standalone addresses. PrefixLength and
+// Gateway are the defaults for all ranges and addresses. A zero PrefixLength
+// or an invalid Gateway of a standalone address inherits the value of the
+// pool.
+type Pool struct {
+	Name         string
+	PrefixLength int
+	Gateway      netip.Addr
+	Ranges       []Range
+	Addresses    []Address
+}
+
+// Validate checks that all ranges are well-formed and that every range and
+// address has a prefix length and gateway.
+func (p *Pool) Validate() error {
+	for _, r := range p.Ranges {
+		if !r.From.IsValid() || !r.To.IsValid() || r.From.BitLen() != r.To.BitLen() || !r.From.Less(r.To) {
+			return newError(ErrInvalid, fmt.Sprintf("Range '%s-%s' of pool '%s' is invalid, 'from_ip' must be smaller than 'to_ip'.", r.From, r.To, p.Name))
+		}
+		if r.Step < 0 || r.Align < 0 || r.Align&(r.Align-1) != 0 {
+			return newError(ErrInvalid, fmt.Sprintf("Range '%s-%s' of pool '%s' is invalid, 'step' must not be negative and 'align' must be a power of two.", r.From, r.To, p.Name))
+		}
+		if err := p.validateSettings(fmt.Sprintf("Range '%s-%s'", r.From, r.To), r.From, r.PrefixLength, r.Gateway); err != nil {
+			return err
+		}
+	}
+	for _, a := range p.Addresses {
+		if !a.IP.IsValid() {
+			return newError(ErrInvalid, fmt.Sprintf("Pool '%s' contains an invalid IP address.", p.Name))
+		}
+		if err := p.validateSettings(fmt.Sprintf("IP '%s'", a.IP), a.IP, a.PrefixLength, a.Gateway); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Pool) validateSettings(name string, ip netip.Addr, prefixLength int, gateway netip.Addr) error {
+	if prefixLength == 0 {
+		prefixLength = p.PrefixLength
+	}
+	if !gateway.IsValid() {
+		gateway = p.Gateway
+	}
+	if prefixLength <= 0 || prefixLength > ip.BitLen() {
+		return newError(ErrInvalid, fmt.Sprintf("%s of pool '%s' has no valid prefix length.", name, p.Name))
+	}
+	if !gateway.IsValid() {
+		return newError(ErrInvalid, fmt.Sprintf("%s of pool '%s' has no gateway.", name, p.Name))
+	}
+	return nil
+}
+
+// Expand returns all addresses of the pool, ranges first and then standalone
+// addresses, each in declaration order. If any range or address has a
+// priority, addresses are ordered by descending priority, keeping this order
+// for addresses with the same priority.
+func (p *Pool) Expand() []Address {
+	addresses := p.ExpandDeclared()
+	for _, a := range addresses {
+		if a.Priority != 0 {
+			sort.SliceStable(addresses, func(i, j int) bool { return addresses[i].Priority > addresses[j].Priority })
+			break
+		}
+	}
+	return addresses
+}
+
+// ExpandDeclared returns all addresses of the pool, ranges first and then
+// standalone addresses, each in declaration order regardless of priority.
+func (p *Pool) ExpandDeclared() []Address {
+	addresses := make([]Address, 0)
+	for _, r := range p.Ranges {
+		addresses = append(addresses, p.expandRange(r)...)
+	}
+	for _, a := range p.Addresses {
+		addresses = append(addresses, p.resolveAddress(a))
+	}
+	return addresses
+}
+
+// Lookup returns the address ip with the prefix length and gateway of the
+// first range or standalone address containing it, and false if the pool
+// does not contain ip.
+func (p *Pool) Lookup(ip netip.Addr) (Address, bool) {
+	for _, r := range p.Ranges {
+		if r.Contains(ip) {
+			return p.resolveAddress(Address{IP: ip, PrefixLength: r.PrefixLength, Gateway: r.Gateway, Priority: r.Priority, Weight: r.Weight}), true
+		}
+	}
+	for _, a := range p.Addresses {
+		if a.IP == ip {
+			return p.resolveAddress(a), true
+		}
+	}
+	return Address{}, false
+}
+
+// Groups returns the addresses of the pool grouped by range. Standalone
+// addresses are grouped by their prefix length and gateway.
+func (p *Pool) Groups() [][]Address {
+	groups := make([][]Address, 0)
+	for _, r := range p.Ranges {
+		groups = append(groups, p.expandRange(r))
+	}
+	standalone := make(map[Address]int)
+	for _, a := range p.Addresses {
+		address := p.resolveAddress(a)
+		key := Address{PrefixLength: address.PrefixLength, Gateway: address.Gateway}
+		g, ok := standalone[key]
+		if !ok {
+			g = len(groups)
+			standalone[key] = g
+			groups = append(groups, make([]Address, 0))
+		}
+		groups[g] = append(groups[g], address)
+	}
+	return groups
+}
+
+// Size returns the number of addresses of the pool.
+func (p *Pool) Size() int {
+	return len(p.Expand())
+}
+
+// LinkPrefixes returns all aligned prefixes of the given length whose
+// addresses are all part of the pool, in pool order.
+func (p *Pool) LinkPrefixes(bits int) []netip.Prefix {
+	addresses := p.Expand()
+	inPool := make(map[netip.Addr]bool, len(addresses))
+	for _, a := range addresses {
+		inPool[a.IP] = true
+	}
+	prefixes := make([]netip.Prefix, 0)
+	for _, a := range addresses {
+		if bits > a.IP.BitLen() {
+			continue
+		}
+		prefix := netip.PrefixFrom(a.IP, bits)
+		if prefix.Masked().Addr() != a.IP {
+			continue
+		}
+		complete := true
+		last := LastAddr(prefix)
+		for ip := a.IP; ; ip = ip.Next() {
+			if !inPool[ip] {
+				complete = false
+				break
+			}
+			if ip == last {
+				break
+			}
+		}
+		if complete {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+func (p *Pool) expandRange(r Range) []Address {
+	addresses := make([]Address, 0)
+	if !r.From.IsValid() || r.From.BitLen() != r.To.BitLen() || r.To.Less(r.From) {
+		return addresses
+	}
+	prefixLength := r.PrefixLength
+	if prefixLength == 0 {
+		prefixLength = p.PrefixLength
+	}
+	gateway := r.Gateway
+	if !gateway.IsValid() {
+		gateway = p.Gateway
+	}
+	start, ok := r.start()
+	if !ok {
+		return addresses
+	}
+	step := uint64(max(r.Step, 1))
+	for ip, ok := start, true; ok && !r.To.Less(ip); ip, ok = addOffset(ip, step) {
+		addresses = append(addresses, Address{IP: ip, PrefixLength: prefixLength, Gateway: gateway, Priority: r.Priority, Weight: r.Weight})
+	}
+	return addresses
+}
+
+// Contains returns true if ip is one of the addresses of the range, taking
+// Step and Align into account.
+func (r Range) Contains(ip netip.Addr) bool {
+	if !r.From.IsValid() || ip.BitLen() != r.From.BitLen() || r.To.Less(ip) {
+		return false
+	}
+	start, ok := r.start()
+	if !ok || ip.Less(start) {
+		return false
+	}
+	if r.Step <= 1 {
+		return true
+	}
+	// remainder of the 128 bit distance to the first address
+	a, b := ip.As16(), start.As16()
+	lo, borrow := bits.Sub64(binary.BigEndian.Uint64(a[8:]), binary.BigEndian.Uint64(b[8:]), 0)
+	hi, _ := bits.Sub64(binary.BigEndian.Uint64(a[:8]), binary.BigEndian.Uint64(b[:8]), borrow)
+	return bits.Rem64(hi, lo, uint64(r.Step)) == 0
+}
+
+// start returns the first address of the range, which is From moved to the
+// next multiple of Align, and false if the range has no such address.
+func (r Range) start() (netip.Addr, bool) {
+	if !r.From.IsValid() || r.From.BitLen() != r.To.BitLen() || r.To.Less(r.From) {
+		return netip.Addr{}, false
+	}
+	if r.Align <= 1 {
+		return r.From, true
+	}
+	// distance to the next multiple of align, which is a power of two
+	start, ok := addOffset(r.From, -lowBits(r.From)&uint64(r.Align-1))
+	if !ok || r.To.Less(start) {
+		return netip.Addr{}, false
+	}
+	return start, true
+}
+
+// lowBits returns the lowest 64 bits of an address.
+func lowBits(ip netip.Addr) uint64 {
+	b := ip.As16()
+	return binary.BigEndian.Uint64(b[8:])
+}
+
+// addOffset returns the address n addresses after ip and false if it is
+// beyond the last address of the address family.
+func addOffset(ip netip.Addr, n uint64) (netip.Addr, bool) {
+	if ip.Is4() {
+		b := ip.As4()
+		v := uint64(binary.BigEndian.Uint32(b[:]))
+		if n > math.MaxUint32-v {
+			return netip.Addr{}, false
+		}
+		binary.BigEndian.PutUint32(b[:], uint32(v+n))
+		return netip.AddrFrom4(b), true
+	}
+	b := ip.As16()
+	lo, carry := bits.Add64(binary.BigEndian.Uint64(b[8:]), n, 0)
+	hi, carry := bits.Add64(binary.BigEndian.Uint64(b[:8]), 0, carry)
+	if carry != 0 {
+		return netip.Addr{}, false
+	}
+	binary.BigEndian.PutUint64(b[:8], hi)
+	binary.BigEndian.PutUint64(b[8:], lo)
+	return netip.AddrFrom16(b).WithZone(ip.Zone()), true
+}
+
+func (p *Pool) resolveAddress(a Address) Address {
+	if a.PrefixLength == 0 {
+		a.PrefixLength = p.PrefixLength
+	}
+	if !a.Gateway.IsValid() {
+		a.Gateway = p.Gateway
+	}
+	return a
+}
@@ -0,0 +1,260 @@
+package ipam
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func testPool() *Pool {
+	return &Pool{
+		Name:         "POOL1",
+		PrefixLength: 24,
+		Gateway:      netip.MustParseAddr("1.1.1.254"),
+		Ranges: []Range{
+			{From: netip.MustParseAddr("1.1.1.1"), To: netip.MustParseAddr("1.1.1.2"), PrefixLength: 22, Gateway: netip.MustParseAddr("1.1.1.201")},
+		},
+		Addresses: []Address{
+			{IP: netip.MustParseAddr("1.1.1.10"), PrefixLength: 23, Gateway: netip.MustParseAddr("1.1.1.200")},
+			{IP: netip.MustParseAddr("1.1.1.11")},
+			{IP: netip.MustParseAddr("1.1.1.12")},
+		},
+	}
+}
+
+func addr(s string) netip.Addr {
+	return netip.MustParseAddr(s)
+}
+
+func ips(addresses []Address) []string {
+	s := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		s = append(s, a.IP.String())
+	}
+	return s
+}
+
+func TestPoolExpand(t *testing.T) {
+	want := []Address{
+		{IP: addr("1.1.1.1"), PrefixLength: 22, Gateway: addr("1.1.1.201")},
+		{IP: addr("1.1.1.2"), PrefixLength: 22, Gateway: addr("1.1.1.201")},
+		{IP: addr("1.1.1.10"), PrefixLength: 23, Gateway: addr("1.1.1.200")},
+		{IP: addr("1.1.1.11"), PrefixLength: 24, Gateway: addr("1.1.1.254")},
+		{IP: addr("1.1.1.12"), PrefixLength: 24, Gateway: addr("1.1.1.254")},
+	}
+	if got := testPool().Expand(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand() = %v, want %v", got, want)
+	}
+	if got := testPool().Size(); got != len(want) {
+		t.Errorf("Size() = %d, want %d", got, len(want))
+	}
+}
+
+func TestPoolExpandRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []string
+	}{
+		{"ipv4", "10.0.0.254", "10.0.1.1", []string{"10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1"}},
+		{"ipv6", "2001:db8::fffe", "2001:db8::1:0", []string{"2001:db8::fffe", "2001:db8::ffff", "2001:db8::1:0"}},
+		{"end of address space", "255.255.255.254", "255.255.255.255", []string{"255.255.255.254", "255.255.255.255"}},
+		{"single", "10.0.0.1", "10.0.0.1", []string{"10.0.0.1"}},
+		{"reversed", "10.0.0.2", "10.0.0.1", []string{}},
+		{"mixed families", "10.0.0.1", "2001:db8::1", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pool{PrefixLength: 24, Ranges: []Range{{From: addr(tt.from), To: addr(tt.to)}}}
+			if got := ips(p.Expand()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolLookup(t *testing.T) {
+	p := testPool()
+	for _, want := range p.Expand() {
+		if got, ok := p.Lookup(want.IP); !ok || got != want {
+			t.Errorf("Lookup(%s) = %+v, %v, want %+v", want.IP, got, ok, want)
+		}
+	}
+	for _, ip := range []string{"1.1.1.3", "1.1.1.254", "::ffff:1.1.1.1"} {
+		if got, ok := p.Lookup(addr(ip)); ok {
+			t.Errorf("Lookup(%s) = %+v, want no address", ip, got)
+		}
+	}
+}
+
+func TestPoolExpandStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		step  int
+		align int
+		want  []string
+	}{
+		{"step", "10.0.0.1", "10.0.0.10", 4, 0, []string{"10.0.0.1", "10.0.0.5", "10.0.0.9"}},
+		{"align", "10.0.0.1", "10.0.0.10", 0, 4, []string{"10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7", "10.0.0.8", "10.0.0.9", "10.0.0.10"}},
+		{"step and align", "10.0.0.1", "10.0.0.20", 8, 8, []string{"10.0.0.8", "10.0.0.16"}},
+		{"aligned start", "10.0.0.252", "10.0.1.3", 4, 4, []string{"10.0.0.252", "10.0.1.0"}},
+		{"ipv6", "2001:db8::1", "2001:db8::20", 16, 16, []string{"2001:db8::10", "2001:db8::20"}},
+		{"no aligned address", "10.0.0.1", "10.0.0.6", 0, 8, []string{}},
+		{"step larger than range", "10.0.0.1", "10.0.0.6", 8, 0, []string{"10.0.0.1"}},
+		{"large step and align", "10.0.0.1", "11.255.255.255", 1 << 23, 1 << 24, []string{"11.0.0.0", "11.128.0.0"}},
+		{"end of ipv4", "255.255.255.250", "255.255.255.255", 4, 0, []string{"255.255.255.250", "255.255.255.254"}},
+		{"end of ipv6", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff0", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", 8, 8, []string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff0", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff8"}},
+		{"align beyond end of ipv4", "255.255.255.250", "255.255.255.255", 0, 1 << 32, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pool{PrefixLength: 24, Ranges: []Range{{From: addr(tt.from), To: addr(tt.to), Step: tt.step, Align: tt.align}}}
+			if got := ips(p.Expand()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	ranges := []Range{
+		{From: addr("10.0.0.1"), To: addr("10.0.0.40")},
+		{From: addr("10.0.0.1"), To: addr("10.0.0.40"), Step: 3},
+		{From: addr("10.0.0.1"), To: addr("10.0.0.40"), Align: 8},
+		{From: addr("10.0.0.3"), To: addr("10.0.0.40"), Step: 6, Align: 4},
+		{From: addr("10.0.0.3"), To: addr("10.0.0.6"), Align: 8},
+		{From: addr("2001:db8::1"), To: addr("2001:db8::40"), Step: 5, Align: 16},
+	}
+	for _, r := range ranges {
+		p := &Pool{Ranges: []Range{r}}
+		expanded := make(map[netip.Addr]bool)
+		for _, a := range p.Expand() {
+			expanded[a.IP] = true
+		}
+		for ip := r.From.Prev(); ip != r.To.Next().Next(); ip = ip.Next() {
+			if got := r.Contains(ip); got != expanded[ip] {
+				t.Errorf("%+v Contains(%s) = %v, want %v", r, ip, got, expanded[ip])
+			}
+		}
+		if r.Contains(addr("::ffff:10.0.0.4")) {
+			t.Errorf("%+v Contains(::ffff:10.0.0.4) = true, want false", r)
+		}
+	}
+}
+
+func TestPoolExpandPriority(t *testing.T) {
+	p := &Pool{
+		PrefixLength: 24,
+		Gateway:      addr("10.0.0.254"),
+		Ranges: []Range{
+			{From: addr("10.0.0.1"), To: addr("10.0.0.2")},
+			{From: addr("10.0.1.1"), To: addr("10.0.1.2"), Priority: 10},
+			{From: addr("10.0.2.1"), To: addr("10.0.2.2"), Priority: -1},
+		},
+		Addresses: []Address{{IP: addr("10.0.3.1"), Priority: 10}, {IP: addr("10.0.4.1")}},
+	}
+	want := []string{"10.0.1.1", "10.0.1.2", "10.0.3.1", "10.0.0.1", "10.0.0.2", "10.0.4.1", "10.0.2.1", "10.0.2.2"}
+	if got := ips(p.Expand()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand() = %v, want %v", got, want)
+	}
+	want = []string{"10.0.0.1", "10.0.0.2", "10.0.1.1", "10.0.1.2", "10.0.2.1", "10.0.2.2", "10.0.3.1", "10.0.4.1"}
+	if got := ips(p.ExpandDeclared()); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandDeclared() = %v, want %v", got, want)
+	}
+}
+
+func TestPoolGroups(t *testing.T) {
+	want := [][]string{
+		{"1.1.1.1", "1.1.1.2"},
+		{"1.1.1.10"},
+		{"1.1.1.11", "1.1.1.12"},
+	}
+	groups := testPool().Groups()
+	got := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		got = append(got, ips(g))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Groups() = %v, want %v", got, want)
+	}
+}
+
+func TestPoolValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    Pool
+		wantErr bool
+	}{
+		{"valid", *testPool(), false},
+		{"reversed range", Pool{PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.2"), To: addr("10.0.0.1")}}}, true},
+		{"mixed families", Pool{PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("2001:db8::1")}}}, true},
+		{"missing prefix length", Pool{Gateway: addr("10.0.0.254"), Addresses: []Address{{IP: addr("10.0.0.1")}}}, true},
+		{"prefix length too long", Pool{PrefixLength: 64, Gateway: addr("10.0.0.254"), Addresses: []Address{{IP: addr("10.0.0.1")}}}, true},
+		{"missing gateway", Pool{PrefixLength: 24, Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.2")}}}, true},
+		{"invalid address", Pool{PrefixLength: 24, Gateway: addr("10.0.0.254"), Addresses: []Address{{}}}, true},
+		{"negative step", Pool{PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.2"), Step: -1}}}, true},
+		{"align not a power of two", Pool{PrefixLength: 24, Gateway: addr("10.0.0.254"), Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.2"), Align: 6}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pool.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestPoolLinkPrefixes(t *testing.T) {
+	p := &Pool{PrefixLength: 24, Ranges: []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.9")}}}
+	tests := []struct {
+		bits int
+		want []netip.Prefix
+	}{
+		{31, []netip.Prefix{netip.MustParsePrefix("10.0.0.2/31"), netip.MustParsePrefix("10.0.0.4/31"), netip.MustParsePrefix("10.0.0.6/31"), netip.MustParsePrefix("10.0.0.8/31")}},
+		{30, []netip.Prefix{netip.MustParsePrefix("10.0.0.4/30")}},
+		{29, []netip.Prefix{}},
+		{64, []netip.Prefix{}},
+	}
+	for _, tt := range tests {
+		if got := p.LinkPrefixes(tt.bits); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LinkPrefixes(%d) = %v, want %v", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestRangeExclude(t *testing.T) {
+	r := Range{From: addr("10.0.0.1"), To: addr("10.0.0.254"), PrefixLength: 24, Gateway: addr("10.0.0.1")}
+	tests := []struct {
+		name     string
+		excluded []Range
+		want     []Range
+	}{
+		{"none", nil, []Range{r}},
+		{"other family", []Range{{From: addr("2001:db8::"), To: addr("2001:db8::ffff")}}, []Range{r}},
+		{"outside", []Range{{From: addr("10.0.1.1"), To: addr("10.0.1.10")}}, []Range{r}},
+		{"all", []Range{{From: addr("10.0.0.0"), To: addr("10.0.0.255")}}, []Range{}},
+		{"first address", []Range{{From: addr("10.0.0.1"), To: addr("10.0.0.1")}}, []Range{
+			{From: addr("10.0.0.2"), To: addr("10.0.0.254"), PrefixLength: 24, Gateway: addr("10.0.0.1")},
+		}},
+		{"middle", []Range{{From: addr("10.0.0.10"), To: addr("10.0.0.19")}, {From: addr("10.0.0.253"), To: addr("10.0.0.253")}}, []Range{
+			{From: addr("10.0.0.1"), To: addr("10.0.0.9"), PrefixLength: 24, Gateway: addr("10.0.0.1")},
+			{From: addr("10.0.0.20"), To: addr("10.0.0.252"), PrefixLength: 24, Gateway: addr("10.0.0.1")},
+			{From: addr("10.0.0.254"), To: addr("10.0.0.254"), PrefixLength: 24, Gateway: addr("10.0.0.1")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Exclude(tt.excluded...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Exclude() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
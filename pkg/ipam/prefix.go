@@ -0,0 +1,141 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// PrefixPool is a named set of parent prefixes child prefixes are allocated
+// from.
+type PrefixPool struct {
+	Name     string
+	Prefixes []netip.Prefix
+}
+
+// PrefixAllocator allocates child prefixes of a prefix pool.
+type PrefixAllocator struct {
+	Pool *PrefixPool
+}
+
+// Allocate returns a prefix of the requested length for every key. Existing
+// prefixes with the requested length are kept if they are part of the pool,
+// all others are reallocated. New prefixes are allocated largest first, each
+// in the smallest free block it fits in.
+func (a *PrefixAllocator) Allocate(requests map[string]int, existing map[string]netip.Prefix) (map[string]netip.Prefix, error) {
+	keys := make([]string, 0, len(requests))
+	for k := range requests {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// keep existing prefixes
+	allocations := make(map[string]netip.Prefix, len(requests))
+	var used []netip.Prefix
+	var pending []string
+	for _, k := range keys {
+		if requests[k] < 0 || requests[k] > 128 {
+			return nil, newError(ErrInvalid, fmt.Sprintf("'prefix_length' of '%s' must be a number between 0 and 128.", k))
+		}
+		if prefix, ok := existing[k]; ok && prefix.IsValid() && prefix.Bits() == requests[k] && a.Pool.Contains(prefix) && !overlapsAny(prefix, used) {
+			allocations[k] = prefix
+			used = append(used, prefix)
+			continue
+		}
+		pending = append(pending, k)
+	}
+
+	// allocate larger prefixes first to reduce fragmentation
+	sort.SliceStable(pending, func(i, j int) bool {
+		return requests[pending[i]] < requests[pending[j]]
+	})
+
+	for _, k := range pending {
+		bits := requests[k]
+		// find the smallest free block the prefix fits in
+		var best netip.Prefix
+		for _, parent := range a.Pool.Prefixes {
+			for _, free := range FreePrefixes(parent, used) {
+				if free.Bits() > bits || bits > free.Addr().BitLen() {
+					continue
+				}
+				if !best.IsValid() || free.Bits() > best.Bits() {
+					best = free
+				}
+			}
+		}
+		if !best.IsValid() {
+			return nil, newError(ErrExhausted, fmt.Sprintf("Prefix pool '%s' does not have a free /%d prefix for '%s'.", a.Pool.Name, bits, k))
+		}
+		prefix := netip.PrefixFrom(best.Addr(), bits)
+		allocations[k] = prefix
+		used = append(used, prefix)
+	}
+
+	return allocations, nil
+}
+
+// Contains returns true if a prefix is part of a prefix of the pool.
+func (p *PrefixPool) Contains(prefix netip.Prefix) bool {
+	for _, parent := range p.Prefixes {
+		if parent.Bits() <= prefix.Bits() && parent.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// LastAddr returns the last address of a prefix.
+func LastAddr(prefix netip.Prefix) netip.Addr {
+	prefix = prefix.Masked()
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// UsableRange returns the first and last usable host address of a prefix.
+// Point-to-point prefixes (/31, /127) and host prefixes use all addresses.
+func UsableRange(prefix netip.Prefix) (netip.Addr, netip.Addr) {
+	prefix = prefix.Masked()
+	first := prefix.Addr()
+	last := LastAddr(prefix)
+	if prefix.Addr().BitLen()-prefix.Bits() <= 1 {
+		return first, last
+	}
+	if first.Is4() {
+		return first.Next(), last.Prev()
+	}
+	return first.Next(), last
+}
+
+// FreePrefixes returns the smallest set of prefixes covering all addresses of
+// parent which are not part of any used prefix.
+func FreePrefixes(parent netip.Prefix, used []netip.Prefix) []netip.Prefix {
+	overlaps := false
+	for _, u := range used {
+		if u.Bits() <= parent.Bits() && u.Contains(parent.Addr()) {
+			return nil
+		}
+		if u.Overlaps(parent) {
+			overlaps = true
+		}
+	}
+	if !overlaps {
+		return []netip.Prefix{parent}
+	}
+	lower := netip.PrefixFrom(parent.Addr(), parent.Bits()+1)
+	upper := netip.PrefixFrom(LastAddr(lower).Next(), parent.Bits()+1)
+	return append(FreePrefixes(lower, used), FreePrefixes(upper, used)...)
+}
+
+func overlapsAny(prefix netip.Prefix, prefixes []netip.Prefix) bool {
+	for _, p := range prefixes {
+		if p.Overlaps(prefix) {
+			return true
+		}
+	}
+	return false
+}
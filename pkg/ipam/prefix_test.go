@@ -0,0 +1,150 @@
+package ipam
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func prefix(s string) netip.Prefix {
+	return netip.MustParsePrefix(s)
+}
+
+func TestLastAddr(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"10.0.0.0/24", "10.0.0.255"},
+		{"10.0.0.5/30", "10.0.0.7"},
+		{"10.0.0.1/32", "10.0.0.1"},
+		{"0.0.0.0/0", "255.255.255.255"},
+		{"2001:db8::/64", "2001:db8::ffff:ffff:ffff:ffff"},
+		{"2001:db8::/127", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		if got := LastAddr(prefix(tt.prefix)).String(); got != tt.want {
+			t.Errorf("LastAddr(%s) = %s, want %s", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestUsableRange(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		wantFirst string
+		wantLast  string
+	}{
+		{"10.0.0.0/24", "10.0.0.1", "10.0.0.254"},
+		{"10.0.0.4/30", "10.0.0.5", "10.0.0.6"},
+		{"10.0.0.4/31", "10.0.0.4", "10.0.0.5"},
+		{"10.0.0.4/32", "10.0.0.4", "10.0.0.4"},
+		{"2001:db8::/64", "2001:db8::1", "2001:db8::ffff:ffff:ffff:ffff"},
+		{"2001:db8::/127", "2001:db8::", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		first, last := UsableRange(prefix(tt.prefix))
+		if first.String() != tt.wantFirst || last.String() != tt.wantLast {
+			t.Errorf("UsableRange(%s) = %s, %s, want %s, %s", tt.prefix, first, last, tt.wantFirst, tt.wantLast)
+		}
+	}
+}
+
+func TestFreePrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		used []netip.Prefix
+		want []netip.Prefix
+	}{
+		{"unused", nil, []netip.Prefix{prefix("10.0.0.0/24")}},
+		{"fully used", []netip.Prefix{prefix("10.0.0.0/16")}, nil},
+		{"other family", []netip.Prefix{prefix("2001:db8::/32")}, []netip.Prefix{prefix("10.0.0.0/24")}},
+		{"first half", []netip.Prefix{prefix("10.0.0.0/25")}, []netip.Prefix{prefix("10.0.0.128/25")}},
+		{"single address", []netip.Prefix{prefix("10.0.0.5/32")}, []netip.Prefix{
+			prefix("10.0.0.0/30"), prefix("10.0.0.4/32"), prefix("10.0.0.6/31"), prefix("10.0.0.8/29"),
+			prefix("10.0.0.16/28"), prefix("10.0.0.32/27"), prefix("10.0.0.64/26"), prefix("10.0.0.128/25"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FreePrefixes(prefix("10.0.0.0/24"), tt.used); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FreePrefixes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixAllocate(t *testing.T) {
+	pool := &PrefixPool{Name: "POOL", Prefixes: []netip.Prefix{prefix("10.0.0.0/24"), prefix("10.0.1.0/24"), prefix("2001:db8::/48")}}
+	tests := []struct {
+		name     string
+		requests map[string]int
+		existing map[string]netip.Prefix
+		want     map[string]netip.Prefix
+		wantErr  error
+	}{
+		{
+			name:     "largest first",
+			requests: map[string]int{"a": 31, "b": 25, "c": 24},
+			want:     map[string]netip.Prefix{"a": prefix("10.0.1.128/31"), "b": prefix("10.0.1.0/25"), "c": prefix("10.0.0.0/24")},
+		},
+		{
+			name:     "best fit",
+			requests: map[string]int{"a": 25, "b": 31, "c": 26},
+			existing: map[string]netip.Prefix{"a": prefix("10.0.0.0/25")},
+			want:     map[string]netip.Prefix{"a": prefix("10.0.0.0/25"), "b": prefix("10.0.0.192/31"), "c": prefix("10.0.0.128/26")},
+		},
+		{
+			name:     "changed prefix length",
+			requests: map[string]int{"a": 26},
+			existing: map[string]netip.Prefix{"a": prefix("10.0.0.0/25")},
+			want:     map[string]netip.Prefix{"a": prefix("10.0.0.0/26")},
+		},
+		{
+			name:     "overlapping existing",
+			requests: map[string]int{"a": 24, "b": 25},
+			existing: map[string]netip.Prefix{"a": prefix("10.0.0.0/24"), "b": prefix("10.0.0.0/25")},
+			want:     map[string]netip.Prefix{"a": prefix("10.0.0.0/24"), "b": prefix("10.0.1.0/25")},
+		},
+		{
+			name:     "removed parent prefix",
+			requests: map[string]int{"a": 24, "b": 25},
+			existing: map[string]netip.Prefix{"a": prefix("10.0.2.0/24"), "b": prefix("10.0.0.0/25")},
+			want:     map[string]netip.Prefix{"a": prefix("10.0.1.0/24"), "b": prefix("10.0.0.0/25")},
+		},
+		{
+			name:     "ipv6",
+			requests: map[string]int{"a": 64, "b": 24},
+			want:     map[string]netip.Prefix{"a": prefix("2001:db8::/64"), "b": prefix("10.0.0.0/24")},
+		},
+		{
+			name:     "exhausted",
+			requests: map[string]int{"a": 23},
+			wantErr:  ErrExhausted,
+		},
+		{
+			name:     "invalid",
+			requests: map[string]int{"a": 129},
+			wantErr:  ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := PrefixAllocator{Pool: pool}
+			got, err := a.Allocate(tt.requests, tt.existing)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package ipam
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Strategy selects the addresses for a new allocation. Select is called with
+// the free addresses of a pool in pool order and returns at most n of them.
+type Strategy interface {
+	Select(free []Address, n int) []Address
+}
+
+// FirstFree selects the first free addresses in pool order. It is the default
+// strategy.
+type FirstFree struct{}
+
+func (FirstFree) Select(free []Address, n int) []Address {
+	if n > len(free) {
+		n = len(free)
+	}
+	return free[:n]
+}
+
+// LastFree selects the last free addresses in pool order.
+type LastFree struct{}
+
+func (LastFree) Select(free []Address, n int) []Address {
+	selected := make([]Address, 0, n)
+	for i := len(free) - 1; i >= 0 && len(selected) < n; i-- {
+		selected = append(selected, free[i])
+	}
+	return selected
+}
+
+// Random selects random free addresses, proportionally to their weight if
+// the weights differ. A nil Rand uses the global source of math/rand.
+type Random struct {
+	Rand *rand.Rand
+}
+
+func (s Random) Select(free []Address, n int) []Address {
+	for _, f := range free {
+		if max(f.Weight, 1) != max(free[0].Weight, 1) {
+			return s.selectWeighted(free, n)
+		}
+	}
+	perm := rand.Perm
+	if s.Rand != nil {
+		perm = s.Rand.Perm
+	}
+	selected := make([]Address, 0, n)
+	for _, i := range perm(len(free)) {
+		if len(selected) == n {
+			break
+		}
+		selected = append(selected, free[i])
+	}
+	return selected
+}
+
+// selectWeighted selects n addresses without replacement with probabilities
+// proportional to their weight, using the keys u^(1/weight) of
+// Efraimidis and Spirakis.
+func (s Random) selectWeighted(free []Address, n int) []Address {
+	random := rand.Float64
+	if s.Rand != nil {
+		random = s.Rand.Float64
+	}
+	keys := make([]float64, len(free))
+	order := make([]int, len(free))
+	for i, f := range free {
+		keys[i] = math.Pow(random(), 1/float64(max(f.Weight, 1)))
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return keys[order[i]] > keys[order[j]] })
+	selected := make([]Address, 0, n)
+	for _, i := range order {
+		if len(selected) == n {
+			break
+		}
+		selected = append(selected, free[i])
+	}
+	return selected
+}
@@ -0,0 +1,63 @@
+package ipam
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStrategies(t *testing.T) {
+	free := testPool().Expand()
+	tests := []struct {
+		name     string
+		strategy Strategy
+		n        int
+		want     []string
+	}{
+		{"first free", FirstFree{}, 2, []string{"1.1.1.1", "1.1.1.2"}},
+		{"first free more than free", FirstFree{}, 10, []string{"1.1.1.1", "1.1.1.2", "1.1.1.10", "1.1.1.11", "1.1.1.12"}},
+		{"last free", LastFree{}, 2, []string{"1.1.1.12", "1.1.1.11"}},
+		{"last free none", LastFree{}, 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ips(tt.strategy.Select(free, tt.n)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomStrategy(t *testing.T) {
+	free := testPool().Expand()
+	s := Random{Rand: rand.New(rand.NewSource(1))}
+	got := ips(s.Select(free, 3))
+	if len(got) != 3 {
+		t.Fatalf("Select() returned %d addresses, want 3", len(got))
+	}
+	sort.Strings(got)
+	for i := 1; i < len(got); i++ {
+		if got[i] == got[i-1] {
+			t.Errorf("Select() returned duplicate address %s", got[i])
+		}
+	}
+	if got := s.Select(free, 10); len(got) != len(free) {
+		t.Errorf("Select() returned %d addresses, want %d", len(got), len(free))
+	}
+}
+
+func TestRandomStrategyWeights(t *testing.T) {
+	free := []Address{{IP: addr("10.0.0.1"), Weight: 1}, {IP: addr("10.0.0.2"), Weight: 9}}
+	s := Random{Rand: rand.New(rand.NewSource(1))}
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[ips(s.Select(free, 1))[0]]++
+	}
+	if counts["10.0.0.2"] < 850 || counts["10.0.0.2"] > 950 {
+		t.Errorf("Select() selected 10.0.0.2 %d of 1000 times, want about 900", counts["10.0.0.2"])
+	}
+	if got := s.Select(free, 5); len(got) != len(free) {
+		t.Errorf("Select() returned %d addresses, want %d", len(got), len(free))
+	}
+}
@@ -0,0 +1,188 @@
+package ipam
+
+import (
+	"math/big"
+	"net/netip"
+	"sort"
+)
+
+// RangePrefixes returns the smallest set of prefixes exactly covering all
+// addresses from from to to, in ascending order. It returns nil if the
+// addresses are of different families or from is greater than to.
+func RangePrefixes(from, to netip.Addr) []netip.Prefix {
+	from, to = from.Unmap(), to.Unmap()
+	if !from.IsValid() || !to.IsValid() || from.BitLen() != to.BitLen() || to.Less(from) {
+		return nil
+	}
+	var prefixes []netip.Prefix
+	for {
+		// grow the prefix while it stays aligned and within the range
+		bits := from.BitLen()
+		for bits > 0 {
+			p := netip.PrefixFrom(from, bits-1).Masked()
+			if p.Addr() != from || to.Less(LastAddr(p)) {
+				break
+			}
+			bits--
+		}
+		p := netip.PrefixFrom(from, bits)
+		prefixes = append(prefixes, p)
+		last := LastAddr(p)
+		if last == to {
+			return prefixes
+		}
+		from = last.Next()
+	}
+}
+
+// Summarize returns the smallest set of prefixes covering all addresses of
+// the given prefixes, in ascending order with IPv4 before IPv6. Host
+// addresses can be passed as single address prefixes. Prefixes are merged
+// into a common covering prefix as long as the total number of covered
+// addresses which are not part of any given prefix does not exceed
+// maxOvercoverage, preferring merges which cover the fewest additional
+// addresses.
+func Summarize(prefixes []netip.Prefix, maxOvercoverage uint64) []netip.Prefix {
+	// merge overlapping and adjacent prefixes into ranges
+	ranges := make([]Range, 0, len(prefixes))
+	for _, p := range prefixes {
+		if !p.IsValid() {
+			continue
+		}
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		p = p.Masked()
+		ranges = append(ranges, Range{From: p.Addr(), To: LastAddr(p)})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From.Less(ranges[j].From) })
+	var merged []Range
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && merged[n-1].From.BitLen() == r.From.BitLen() {
+			last := &merged[n-1]
+			if !last.To.Next().IsValid() || !last.To.Next().Less(r.From) {
+				if last.To.Less(r.To) {
+					last.To = r.To
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+
+	exact := make([]netip.Prefix, 0, len(merged))
+	for _, r := range merged {
+		exact = append(exact, RangePrefixes(r.From, r.To)...)
+	}
+	if maxOvercoverage == 0 || len(exact) < 2 {
+		return exact
+	}
+
+	// Merging prefixes into a covering prefix adds the addresses of the
+	// covering prefix which are not part of any merged prefix. Since the
+	// prefixes are sorted and disjoint, the merged prefixes are consecutive.
+	type merge struct {
+		prefix      netip.Prefix
+		first, last int
+		cost        *big.Int
+	}
+	result := append([]netip.Prefix(nil), exact...)
+	inside := func(s, p netip.Prefix) bool {
+		return p.Addr().BitLen() == s.Addr().BitLen() && s.Bits() <= p.Bits() && s.Contains(p.Addr())
+	}
+	// candidate returns the merge of the prefixes i and i+1
+	candidate := func(i int) *merge {
+		if result[i].Addr().BitLen() != result[i+1].Addr().BitLen() {
+			return nil
+		}
+		m := &merge{prefix: commonPrefix(result[i], result[i+1]), first: i, last: i + 1}
+		for m.first > 0 && inside(m.prefix, result[m.first-1]) {
+			m.first--
+		}
+		for m.last+1 < len(result) && inside(m.prefix, result[m.last+1]) {
+			m.last++
+		}
+		m.cost = overcoverage(m.prefix, result[m.first:m.last+1])
+		return m
+	}
+	candidates := make([]*merge, len(result)-1)
+	for i := range candidates {
+		candidates[i] = candidate(i)
+	}
+
+	remaining := new(big.Int).SetUint64(maxOvercoverage)
+	for {
+		var best *merge
+		for _, m := range candidates {
+			if m == nil || m.cost.Cmp(remaining) > 0 {
+				continue
+			}
+			if best == nil || m.cost.Cmp(best.cost) < 0 || (m.cost.Cmp(best.cost) == 0 && m.last-m.first > best.last-best.first) {
+				best = m
+			}
+		}
+		if best == nil {
+			return result
+		}
+		first, last := best.first, best.last
+		result = append(append(result[:first:first], best.prefix), result[last+1:]...)
+		remaining.Sub(remaining, best.cost)
+
+		// only merges including or next to the merged prefixes change
+		removed := last - first
+		next := make([]*merge, 0, len(result)-1)
+		for i, m := range candidates {
+			if i >= first && i < last {
+				continue
+			}
+			if m != nil && m.last+1 >= first && m.first-1 <= last {
+				m = nil
+			} else if m != nil && m.first > last {
+				m.first -= removed
+				m.last -= removed
+			}
+			next = append(next, m)
+		}
+		candidates = next
+		for i := range candidates {
+			if candidates[i] == nil {
+				candidates[i] = candidate(i)
+			}
+		}
+	}
+}
+
+// commonPrefix returns the smallest prefix containing both prefixes, which
+// must be of the same family.
+func commonPrefix(a, b netip.Prefix) netip.Prefix {
+	bits := min(a.Bits(), b.Bits())
+	for ; bits > 0; bits-- {
+		p := netip.PrefixFrom(a.Addr(), bits).Masked()
+		if p.Contains(b.Addr()) {
+			return p
+		}
+	}
+	return netip.PrefixFrom(a.Addr(), 0).Masked()
+}
+
+// overcoverage returns the number of addresses of s which are not part of
+// any of the given prefixes, which must be disjoint and inside of s.
+func overcoverage(s netip.Prefix, prefixes []netip.Prefix) *big.Int {
+	if hostBits := s.Addr().BitLen() - s.Bits(); hostBits < 64 {
+		n := uint64(1) << hostBits
+		for _, p := range prefixes {
+			n -= uint64(1) << (p.Addr().BitLen() - p.Bits())
+		}
+		return new(big.Int).SetUint64(n)
+	}
+	n := prefixSize(s)
+	for _, p := range prefixes {
+		n.Sub(n, prefixSize(p))
+	}
+	return n
+}
+
+// prefixSize returns the number of addresses of a prefix.
+func prefixSize(p netip.Prefix) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+}
@@ -0,0 +1,108 @@
+package ipam
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func prefixStrings(prefixes []netip.Prefix) []string {
+	s := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		s = append(s, p.String())
+	}
+	return s
+}
+
+func TestRangePrefixes(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     []string
+	}{
+		{"10.0.0.5", "10.0.0.20", []string{"10.0.0.5/32", "10.0.0.6/31", "10.0.0.8/29", "10.0.0.16/30", "10.0.0.20/32"}},
+		{"10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}},
+		{"10.0.0.1", "10.0.0.1", []string{"10.0.0.1/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"10.0.0.255", "10.0.1.0", []string{"10.0.0.255/32", "10.0.1.0/32"}},
+		{"2001:db8::1", "2001:db8::ffff", []string{"2001:db8::1/128", "2001:db8::2/127", "2001:db8::4/126", "2001:db8::8/125", "2001:db8::10/124", "2001:db8::20/123", "2001:db8::40/122", "2001:db8::80/121", "2001:db8::100/120", "2001:db8::200/119", "2001:db8::400/118", "2001:db8::800/117", "2001:db8::1000/116", "2001:db8::2000/115", "2001:db8::4000/114", "2001:db8::8000/113"}},
+		{"::ffff:10.0.0.0", "10.0.0.3", []string{"10.0.0.0/30"}},
+		{"10.0.0.2", "10.0.0.1", []string{}},
+		{"10.0.0.1", "2001:db8::1", []string{}},
+	}
+	for _, tt := range tests {
+		if got := prefixStrings(RangePrefixes(addr(tt.from), addr(tt.to))); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RangePrefixes(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name            string
+		prefixes        []string
+		maxOvercoverage uint64
+		want            []string
+	}{
+		{
+			name:            "empty",
+			maxOvercoverage: 1,
+			want:            []string{},
+		},
+		{
+			name:     "loopbacks",
+			prefixes: []string{"10.0.0.3/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.0/32", "10.0.0.4/32"},
+			want:     []string{"10.0.0.0/30", "10.0.0.4/32"},
+		},
+		{
+			name:     "overlapping",
+			prefixes: []string{"10.0.0.0/25", "10.0.0.64/26", "10.0.0.128/25", "10.0.0.1/32"},
+			want:     []string{"10.0.0.0/24"},
+		},
+		{
+			name:     "mixed families",
+			prefixes: []string{"2001:db8::1/128", "10.0.0.1/32", "::ffff:10.0.0.0/128", "2001:db8::/128"},
+			want:     []string{"10.0.0.0/31", "2001:db8::/127"},
+		},
+		{
+			name:            "overcoverage",
+			prefixes:        []string{"10.0.0.0/30", "10.0.0.4/32", "10.0.0.6/31"},
+			maxOvercoverage: 1,
+			want:            []string{"10.0.0.0/29"},
+		},
+		{
+			name:            "overcoverage exceeded",
+			prefixes:        []string{"10.0.0.0/30", "10.0.0.4/32"},
+			maxOvercoverage: 2,
+			want:            []string{"10.0.0.0/30", "10.0.0.4/32"},
+		},
+		{
+			name:            "cheapest merge first",
+			prefixes:        []string{"10.0.0.1/32", "10.0.0.2/32", "10.0.0.16/32", "10.0.0.18/32"},
+			maxOvercoverage: 2,
+			want:            []string{"10.0.0.0/30", "10.0.0.16/32", "10.0.0.18/32"},
+		},
+		{
+			name:            "total overcoverage",
+			prefixes:        []string{"10.0.0.1/32", "10.0.0.2/32", "10.0.0.16/32", "10.0.0.18/32"},
+			maxOvercoverage: 4,
+			want:            []string{"10.0.0.0/30", "10.0.0.16/30"},
+		},
+		{
+			name:            "ipv6 overcoverage",
+			prefixes:        []string{"2001:db8::/64", "2001:db8:0:2::/64"},
+			maxOvercoverage: 1 << 63,
+			want:            []string{"2001:db8::/64", "2001:db8:0:2::/64"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes := make([]netip.Prefix, 0, len(tt.prefixes))
+			for _, p := range tt.prefixes {
+				prefixes = append(prefixes, prefix(p))
+			}
+			if got := prefixStrings(Summarize(prefixes, tt.maxOvercoverage)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}